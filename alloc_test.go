@@ -0,0 +1,82 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"testing"
+)
+
+func disabledlogger() slog.StructuredLogger {
+	lf := slog.New()
+	lf.AddEntryHandler(voidhandler{})
+	lf.SetLevel(slf.LevelInfo)
+	logger, _ := lf.WithContext("alloc").WithField("key", "value").(slog.StructuredLogger)
+	return logger
+}
+
+func TestLogger_enabled_success(t *testing.T) {
+	lf := slog.New()
+	lf.SetLevel(slf.LevelWarn)
+	logger, _ := lf.WithContext("ctx").(slog.StructuredLogger)
+	if logger.Enabled(slf.LevelInfo) || !logger.Enabled(slf.LevelWarn) || !logger.Enabled(slf.LevelError) {
+		t.Error("unexpected level check")
+	}
+	lf.SetLevel(slf.LevelDebug, "ctx")
+	if !logger.Enabled(slf.LevelDebug) {
+		t.Error("expected level change to apply")
+	}
+}
+
+func TestLogger_disabledLevel_zeroAllocs_success(t *testing.T) {
+	logger := disabledlogger()
+	for name, fn := range map[string]func(){
+		"Enabled": func() { logger.Enabled(slf.LevelDebug) },
+		"Debug":   func() { logger.Debug("message") },
+		"Debugf":  func() { logger.Debugf("message") },
+		"Log":     func() { logger.Log(slf.LevelDebug, "message") },
+		"Trace":   func() { logger.Debug("message").Trace(nil) },
+	} {
+		if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+			t.Errorf("%v: expected no allocations, %v", name, allocs)
+		}
+	}
+}
+
+// Arguments of the formatting methods are boxed into a slice escaping to the heap at the call
+// site and loggers with fields are constructed before the call, which can only be avoided by
+// guarding the call or by logging typed fields via events.
+func TestLogger_disabledLevel_guarded_zeroAllocs_success(t *testing.T) {
+	logger := disabledlogger()
+	i, s := 1000, "value"
+	for name, fn := range map[string]func(){
+		"Debugf": func() {
+			if logger.Enabled(slf.LevelDebug) {
+				logger.Debugf("i=%v, s=%v", i, s)
+			}
+		},
+		"WithField": func() {
+			if logger.Enabled(slf.LevelDebug) {
+				logger.WithField("i", i).Debug("message")
+			}
+		},
+		"WithTypedFields": func() {
+			if logger.Enabled(slf.LevelDebug) {
+				logger.WithTypedFields(slog.Int64("i", int64(i)), slog.String("s", s)).Debug("message")
+			}
+		},
+		"Events": func() {
+			slog.Events(logger).Level(slf.LevelDebug).Int("i", i).Str("s", s).Msg("message")
+		},
+	} {
+		allocs := testing.AllocsPerRun(100, func() {
+			fn()
+			i++
+		})
+		if allocs != 0 {
+			t.Errorf("%v: expected no allocations, %v", name, allocs)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package basic_test
+
+import (
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/basic"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func colored(t *testing.T, h *basic.Handler) bool {
+	t.Helper()
+	wr := &stringwriter{}
+	h.SetWriter(wr)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+	lf.WithContext("test").Info("message")
+	return strings.Contains(wr.res, "\033[")
+}
+
+func TestHandler_colorMode_success(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	for name, tc := range map[string]struct {
+		mode     basic.ColorMode
+		noColor  string
+		force    string
+		expected bool
+	}{
+		"auto, not a terminal": {basic.ColorAuto, "", "", false},
+		"auto, forced":         {basic.ColorAuto, "", "1", true},
+		"auto, no color":       {basic.ColorAuto, "1", "", false},
+		"auto, no color wins":  {basic.ColorAuto, "1", "1", false},
+		"always":               {basic.ColorAlways, "", "", true},
+		"always, no color":     {basic.ColorAlways, "1", "", true},
+		"never":                {basic.ColorNever, "", "", false},
+		"never, forced":        {basic.ColorNever, "", "1", false},
+	} {
+		os.Setenv("NO_COLOR", tc.noColor)
+		os.Setenv("FORCE_COLOR", tc.force)
+		h := basic.New()
+		h.SetColorMode(tc.mode)
+		if found := colored(t, h); found != tc.expected {
+			t.Errorf("%v: expected colored %v, found %v", name, tc.expected, found)
+		}
+	}
+}
+
+func TestHandler_colorMode_customTemplate_success(t *testing.T) {
+	h := basic.New()
+	h.SetColorMode(basic.ColorNever)
+	if err := h.SetTemplate(basic.StandardTermTemplate); err != nil {
+		t.Fatal(err)
+	}
+	if !colored(t, h) {
+		t.Error("expected the explicit template to be used")
+	}
+}
+
+func TestIsTerminal_success(t *testing.T) {
+	if basic.IsTerminal(&stringwriter{}) {
+		t.Error("expected no terminal for a writer without file descriptor")
+	}
+	f, err := ioutil.TempFile("", "slog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if basic.IsTerminal(f) {
+		t.Error("expected no terminal for a file")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if basic.IsTerminal(w) {
+		t.Error("expected no terminal for a pipe")
+	}
+	if runtime.GOOS != "linux" {
+		return
+	}
+	pty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo terminal available, %v", err)
+	}
+	defer pty.Close()
+	if !basic.IsTerminal(pty) {
+		t.Error("expected a terminal for a pseudo terminal")
+	}
+}
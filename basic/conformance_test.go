@@ -0,0 +1,57 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package basic_test
+
+import (
+	"fmt"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/basic"
+	"github.com/ventu-io/slog/slogtest"
+	"io"
+	"strings"
+	"testing"
+)
+
+// tab separated to be parsed unambiguously
+const conformanceTemplate = "{{.Level}}\t{{.Message}}\t{{if .Error}}{{.Error}}{{end}}\t{{.Fields}}"
+
+func TestHandler_conformance_success(t *testing.T) {
+	slogtest.RunHandlerTests(t, func(w io.Writer) slog.EntryHandler {
+		h := basic.New()
+		h.SetWriter(w)
+		if err := h.SetTemplate(conformanceTemplate); err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}, parse)
+}
+
+func parse(output []byte) ([]slogtest.Record, error) {
+	var res []slogtest.Record
+	for _, line := range strings.Split(strings.TrimSuffix(string(output), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "\t")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		level, err := slogtest.ParseLevel(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		r := slogtest.Record{Level: level, Message: parts[1], Error: parts[2], Fields: make(map[string]string)}
+		if parts[3] != "" {
+			for _, f := range strings.Split(parts[3], "; ") {
+				kv := strings.SplitN(f, "=", 2)
+				if len(kv) != 2 {
+					return nil, fmt.Errorf("unexpected field %q", f)
+				}
+				r.Fields[kv[0]] = kv[1]
+			}
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package basic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+var colornames = map[string]int{
+	"red":    red,
+	"green":  green,
+	"yellow": yellow,
+	"blue":   blue,
+	"gray":   gray,
+}
+
+// builtins returns the template functions described in SetTemplate for the config.
+func (h *Handler) builtins(c *config) template.FuncMap {
+	return template.FuncMap{
+		"pad":      pad,
+		"truncate": truncate,
+		"upper": func(value interface{}) string {
+			return strings.ToUpper(fmt.Sprint(value))
+		},
+		"color": func(name string, value interface{}) (string, error) {
+			color, ok := colornames[name]
+			if !ok {
+				return "", fmt.Errorf("basic: unknown color %q", name)
+			}
+			if !c.colorful {
+				return fmt.Sprint(value), nil
+			}
+			return fmt.Sprintf("\033[%dm%v\033[0m", color, value), nil
+		},
+		"field": func(key string, d *Data) string {
+			value, _ := d.visitor.get(key)
+			return value
+		},
+		"json": func(value interface{}) (string, error) {
+			res, err := json.Marshal(value)
+			return string(res), err
+		},
+		"shortContext": shortcontext,
+		// executed under the lock of the handler
+		"since": func(tm time.Time) time.Duration {
+			return tm.Sub(h.start)
+		},
+	}
+}
+
+func pad(n int, value interface{}) string {
+	s := fmt.Sprint(value)
+	if n < 0 {
+		return fmt.Sprintf("%*s", n*-1, s)
+	}
+	return fmt.Sprintf("%-*s", n, s)
+}
+
+func truncate(n int, value interface{}) string {
+	s := fmt.Sprint(value)
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// shortcontext abbreviates all but the last element of a dotted context to its first character,
+// e.g. app.package.sub to a.p.sub.
+func shortcontext(value interface{}) string {
+	parts := strings.Split(fmt.Sprint(value), ".")
+	for i, part := range parts[:len(parts)-1] {
+		if r, size := utf8.DecodeRuneInString(part); size > 0 {
+			parts[i] = string(r)
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package basic_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/basic"
+	"github.com/ventu-io/slog/slogtest"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func render(t *testing.T, h *basic.Handler, tmpl string, log func(lf slog.LogFactory)) string {
+	t.Helper()
+	if err := h.SetTemplate(tmpl); err != nil {
+		t.Fatal(err)
+	}
+	wr := &stringwriter{}
+	h.SetWriter(wr)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+	log(lf)
+	return wr.res
+}
+
+func TestHandler_templateFuncs_success(t *testing.T) {
+	h := basic.New()
+	h.SetColorMode(basic.ColorNever)
+	info := func(lf slog.LogFactory) {
+		logger := lf.WithContext("app.package.sub").WithField("user", "jdoe").(slog.StructuredLogger)
+		logger.WithTypedFields(slog.Int64("n", 5)).WithError(errors.New("failed")).Info("a long message")
+	}
+	for tmpl, expected := range map[string]string{
+		`[{{.Level | pad 6}}]`:                                  "[INFO  ]",
+		`[{{.Level | pad -6}}]`:                                 "[  INFO]",
+		`{{.Message | truncate 6}}`:                             "a long",
+		`{{.Message | truncate 60}}`:                            "a long message",
+		`{{.Message | upper}}`:                                  "A LONG MESSAGE",
+		`{{.Error | upper}}`:                                    "FAILED",
+		`{{.Level | color "red"}}`:                              "INFO",
+		`{{field "user" .}}/{{field "n" .}}/{{field "none" .}}`: "jdoe/5/",
+		`{{json .FieldMap}}`:                                    `{"context":"app.package.sub","n":5,"user":"jdoe"}`,
+		`{{.Context | shortContext}}: {{.Fields}}`:              "a.p.sub: error=failed; n=5; user=jdoe",
+		`{{.Entry.Level}} {{index .FieldMap "user"}}`:           "INFO jdoe",
+	} {
+		if res := render(t, h, tmpl, info); res != expected+"\n" {
+			t.Errorf("%v: expected %q, found %q", tmpl, expected, res)
+		}
+	}
+}
+
+func TestHandler_templateFuncs_color_success(t *testing.T) {
+	h := basic.New()
+	h.SetColorMode(basic.ColorAlways)
+	res := render(t, h, `{{.Level | color "yellow"}}`, func(lf slog.LogFactory) {
+		lf.WithContext("ctx").Warn("warning")
+	})
+	if res != "\033[33mWARN\033[0m\n" {
+		t.Errorf("unexpected output, %q", res)
+	}
+	if err := h.SetTemplate(`{{.Level | color "purple"}}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Handle(slog.NewEntry(time.Now(), slf.LevelInfo, "message", nil, nil)); err == nil || !strings.Contains(err.Error(), `unknown color "purple"`) {
+		t.Errorf("expected an error, %v", err)
+	}
+}
+
+func TestHandler_templateFuncs_since_success(t *testing.T) {
+	start := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := basic.New()
+	h.SetStart(start)
+	res := render(t, h, `{{since .Entry.Time}}`, func(lf slog.LogFactory) {
+		clock := slogtest.NewFakeClock(start.Add(time.Second))
+		lf.SetClock(clock)
+		lf.WithContext("ctx").Info("first")
+		clock.Add(1500 * time.Millisecond)
+		lf.WithContext("ctx").Info("second")
+	})
+	if res != "1s\n2.5s\n" {
+		t.Errorf("expected durations from the start, %q", res)
+	}
+}
+
+func TestHandler_templateFuncs_since_fromConstruction_success(t *testing.T) {
+	before := time.Now()
+	h := basic.New()
+	h.SetWriter(&stringwriter{})
+	var since time.Duration
+	h.AddFuncs(template.FuncMap{"record": func(d time.Duration) string {
+		since = d
+		return ""
+	}})
+	if err := h.SetTemplate(`{{since .Entry.Time | record}}`); err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(slog.NewEntry(before.Add(time.Hour), slf.LevelInfo, "message", nil, nil))
+	if since <= 59*time.Minute || since > time.Hour {
+		t.Errorf("expected duration from the construction, %v", since)
+	}
+}
+
+func TestHandler_templateFuncs_colorModeChanged_success(t *testing.T) {
+	h := basic.New()
+	h.SetColorMode(basic.ColorNever)
+	if err := h.SetTemplate(`{{.Level | color "yellow"}}`); err != nil {
+		t.Fatal(err)
+	}
+	wr := &stringwriter{}
+	h.SetWriter(wr)
+	h.Handle(slog.NewEntry(time.Now(), slf.LevelWarn, "message", nil, nil))
+	h.SetColorMode(basic.ColorAlways)
+	h.Handle(slog.NewEntry(time.Now(), slf.LevelWarn, "message", nil, nil))
+	if wr.res != "WARN\n\033[33mWARN\033[0m\n" {
+		t.Errorf("unexpected output, %q", wr.res)
+	}
+}
+
+// countingentry counts the calls to Fields.
+type countingentry struct {
+	slog.Entry
+	calls int
+}
+
+func (e *countingentry) Fields() map[string]interface{} {
+	e.calls++
+	return e.Entry.Fields()
+}
+
+func TestHandler_fieldMap_onlyIfReferenced_success(t *testing.T) {
+	h := basic.New()
+	h.SetWriter(&stringwriter{})
+	fields := map[string]interface{}{"key": "value"}
+	calls := func(tmpl string) int {
+		if err := h.SetTemplate(tmpl); err != nil {
+			t.Fatal(err)
+		}
+		e := &countingentry{Entry: slog.NewEntry(time.Now(), slf.LevelInfo, "message", nil, fields)}
+		h.Handle(e)
+		return e.calls
+	}
+	// the fields of entries not produced by slog loggers are visited via Fields
+	without := calls(`{{.Message}} {{.Fields}}`)
+	if with := calls(`{{index .FieldMap "key"}} {{json .FieldMap}}`); with != without+1 {
+		t.Errorf("expected field map once if referenced, %v and %v calls", without, with)
+	}
+	// also if referenced indirectly
+	h.AddFuncs(template.FuncMap{"value": func(key string, d *basic.Data) interface{} {
+		return d.FieldMap()[key]
+	}})
+	if with := calls(`{{value "key" .}}`); with != without+1 {
+		t.Errorf("expected field map if referenced by a function, %v and %v calls", without, with)
+	}
+}
+
+func TestHandler_addFuncs_success(t *testing.T) {
+	h := basic.New()
+	if err := h.SetTemplate(`{{.Message | reverse}}`); err == nil {
+		t.Error("expected an error for an unknown function")
+	}
+	if err := h.AddFuncs(template.FuncMap{"reverse": func(s string) string {
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r)
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if res := render(t, h, `{{.Message | reverse}} {{.Level | upper}}`, func(lf slog.LogFactory) {
+		lf.WithContext("ctx").Info("abc")
+	}); res != "cba INFO\n" {
+		t.Errorf("unexpected output, %q", res)
+	}
+	// overriding a built-in function applies to the template set before
+	wr := &stringwriter{}
+	h.SetWriter(wr)
+	if err := h.AddFuncs(template.FuncMap{"upper": func(v interface{}) string { return "UP" }}); err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(slog.NewEntry(time.Now(), slf.LevelInfo, "abc", nil, nil))
+	if wr.res != "cba UP\n" {
+		t.Errorf("unexpected output, %q", wr.res)
+	}
+}
@@ -14,9 +14,12 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 )
 
 const (
@@ -28,7 +31,8 @@ const (
 )
 
 const (
-	// StandardTermTemplate represents a standard template for terminal output (default).
+	// StandardTermTemplate represents a standard template for terminal output (default for
+	// terminals, see ColorAuto).
 	StandardTermTemplate = "{{.Time}} [\033[{{.Color}}m{{.Level}}\033[0m] {{.Context}}{{if .Caller}} ({{.Caller}}){{end}}: {{.Message}}{{if .Error}} (\033[31merror: {{.Error}}\033[0m){{end}} {{.Fields}}"
 
 	// StandardTextTemplate represents a standard template for text file output or any other writers
@@ -39,61 +43,217 @@ const (
 	StandardTimeFormat = "15:04:05.000"
 )
 
+var (
+	termtemplate = template.Must(template.New("entry").Parse(StandardTermTemplate + "\n"))
+	texttemplate = template.Must(template.New("entry").Parse(StandardTextTemplate + "\n"))
+)
+
+// ColorMode defines whether the handler colours its output when using the standard templates.
+type ColorMode int
+
+const (
+	// ColorAuto colours the output if the writer is a terminal (default). The NO_COLOR environment
+	// variable, if not empty, disables colouring, otherwise FORCE_COLOR, if not empty, enables it
+	// also for writers other than terminals.
+	ColorAuto ColorMode = iota
+
+	// ColorAlways colours the output regardless of the writer and the environment.
+	ColorAlways
+
+	// ColorNever never colours the output.
+	ColorNever
+)
+
 // Handler represents a log entry handler capable of formatting structured log data into
-// a text format (text files, stderr with or without colouring etc).
+// a text format (text files, stderr with or without colouring etc). The handler can be
+// reconfigured while in use.
 type Handler struct {
 	sync.Mutex
+	// *config, replaced as a whole on change
+	config atomic.Value
+	// reference time for the since template function, guarded by the mutex
+	start time.Time
+}
+
+// config represents the settings of the handler.
+type config struct {
 	writer        io.Writer
 	colors        map[slf.Level]int
+	colorMode     ColorMode
 	timeFormatStr string
 	templateStr   string
 	template      *template.Template
+	// true if the template was set explicitly rather than selected by the colour mode
+	custom bool
+	// whether the output is coloured as selected by the colour mode
+	colorful bool
+	// user defined template functions
+	funcs template.FuncMap
 }
 
-// New constructs a new handler with default template, time formatting, colours and stderr as
-// output.
+// New constructs a new handler with default time formatting, colours and stderr as output, using
+// the standard terminal template if stderr is a terminal and the standard text template otherwise
+// (see ColorAuto).
 func New() *Handler {
-	res := &Handler{
+	c := &config{
 		writer:        os.Stderr,
 		colors:        make(map[slf.Level]int),
-		templateStr:   StandardTermTemplate,
 		timeFormatStr: StandardTimeFormat,
-		template:      template.Must(template.New("entry").Parse(StandardTermTemplate + "\n")),
 	}
-	res.colors[slf.LevelDebug] = blue
-	res.colors[slf.LevelInfo] = green
-	res.colors[slf.LevelWarn] = yellow
-	res.colors[slf.LevelError] = red
-	res.colors[slf.LevelPanic] = red
+	c.standard()
+	c.colors[slf.LevelDebug] = blue
+	c.colors[slf.LevelInfo] = green
+	c.colors[slf.LevelWarn] = yellow
+	c.colors[slf.LevelError] = red
+	c.colors[slf.LevelPanic] = red
+	res := &Handler{start: time.Now()}
+	res.config.Store(c)
 	return res
 }
 
-// SetWriter defines the writer to use to output log strings (default: stderr).
+// SetWriter defines the writer to use to output log strings (default: stderr). Unless the template
+// was set explicitly, the standard template is selected anew for the writer.
 func (h *Handler) SetWriter(w io.Writer) {
-	h.writer = w
+	h.update(func(c *config) {
+		c.writer = w
+	})
 }
 
 // SetTemplate defines the formatting of the log string using the standard Go template syntax.
-// See the Data structure for the definition of all supported template fields.
+// See the Data structure for the definition of all supported template fields. An explicitly set
+// template is used regardless of the colour mode. The following functions are available, to be
+// used in pipelines as in {{.Level | pad 5}}, and further ones can be added with AddFuncs:
+//
+//	pad n value           pads the value with spaces to n characters, on the left if n < 0
+//	truncate n value      cuts the value to at most n characters
+//	upper value           converts the value to upper case
+//	color "name" value    colours the value red, green, yellow, blue or gray if colouring is
+//	                      enabled by the colour mode
+//	field "key" .         the formatted value of the field, empty if absent
+//	json value            the value encoded in JSON, e.g. {{json .FieldMap}}
+//	shortContext value    abbreviates all but the last element of a dotted context
+//	since time            the duration from the construction of the handler, or the time set
+//	                      by SetStart, to the time, e.g. {{since .Entry.Time}}
+//
+// Values other than strings are formatted with fmt.
 func (h *Handler) SetTemplate(s string) error {
-	t, err := template.New("entry").Parse(s + "\n")
-	if err != nil {
-		return err
-	}
-	h.templateStr = s
-	h.template = t
-	return nil
+	return h.update(func(c *config) {
+		c.templateStr = s
+		c.template = nil
+		c.custom = true
+	})
+}
+
+// AddFuncs registers template functions in addition to or overriding those described in
+// SetTemplate. Functions must be registered before setting a template using them, a template set
+// before is parsed again to use the overriding functions. It panics as template.Funcs if a value
+// is not a function of an appropriate type.
+func (h *Handler) AddFuncs(funcs template.FuncMap) error {
+	return h.update(func(c *config) {
+		merged := make(template.FuncMap, len(c.funcs)+len(funcs))
+		for name, fn := range c.funcs {
+			merged[name] = fn
+		}
+		for name, fn := range funcs {
+			merged[name] = fn
+		}
+		c.funcs = merged
+		c.template = nil
+	})
+}
+
+// parse parses an explicitly set template with the functions bound to the config.
+func (h *Handler) parse(c *config) (*template.Template, error) {
+	return template.New("entry").Funcs(h.builtins(c)).Funcs(c.funcs).Parse(c.templateStr + "\n")
+}
+
+// SetColorMode defines whether the output is coloured, selecting the standard terminal or text
+// template accordingly unless the template was set explicitly (default: ColorAuto).
+func (h *Handler) SetColorMode(mode ColorMode) {
+	h.update(func(c *config) {
+		c.colorMode = mode
+	})
 }
 
 // SetTimeFormat defines the formatting of time used for output into the template.
 func (h *Handler) SetTimeFormat(f string) {
-	h.timeFormatStr = f
+	h.update(func(c *config) {
+		c.timeFormatStr = f
+	})
 }
 
 // SetColors overwrites the level-colour mapping. Every missing mapping will be replaced by gray.
 func (h *Handler) SetColors(colors map[slf.Level]int) {
 	// no validation: if color not found, gray is used
-	h.colors = colors
+	res := make(map[slf.Level]int, len(colors))
+	for level, color := range colors {
+		res[level] = color
+	}
+	h.update(func(c *config) {
+		c.colors = res
+	})
+}
+
+// SetStart defines the reference time for the since template function (default: the time of
+// construction), e.g. the start of the application or that of a fake clock.
+func (h *Handler) SetStart(tm time.Time) {
+	h.Lock()
+	h.start = tm
+	h.Unlock()
+}
+
+func (h *Handler) load() *config {
+	return h.config.Load().(*config)
+}
+
+// update replaces the config with a modified copy, parsing an explicitly set template anew if
+// reset by modify or if the colouring changed. On a parse error the config is left unchanged.
+func (h *Handler) update(modify func(c *config)) error {
+	h.Lock()
+	defer h.Unlock()
+	old := h.load()
+	c := *old
+	modify(&c)
+	c.standard()
+	if c.custom && (c.template == nil || c.colorful != old.colorful) {
+		t, err := h.parse(&c)
+		if err != nil {
+			return err
+		}
+		c.template = t
+	}
+	h.config.Store(&c)
+	return nil
+}
+
+// standard selects the standard template for the colour mode unless the template was set
+// explicitly.
+func (c *config) standard() {
+	c.colorful = c.colored()
+	if c.custom {
+		return
+	}
+	if c.colorful {
+		c.templateStr, c.template = StandardTermTemplate, termtemplate
+	} else {
+		c.templateStr, c.template = StandardTextTemplate, texttemplate
+	}
+}
+
+func (c *config) colored() bool {
+	switch c.colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if os.Getenv("FORCE_COLOR") != "" {
+		return true
+	}
+	return IsTerminal(c.writer)
 }
 
 // Handle outputs a textual representation of the log entry into a text writer (stderr, file etc.).
@@ -104,67 +264,85 @@ func (h *Handler) Handle(e slog.Entry) (err error) {
 		}
 	}()
 
+	c := h.load()
+	v := &visitor{}
+	slog.VisitFields(e, v)
 	d := &Data{
-		Time:    e.Time().Format(h.timeFormatStr),
+		Time:    e.Time().Format(c.timeFormatStr),
 		Level:   e.Level().String(),
-		Context: h.contextstring(e),
+		Context: c.contextstring(v),
 		Message: e.Message(),
 		Error:   e.Error(),
-		Caller:  h.callerstring(e),
-		Fields:  h.fieldstring(e),
-		Color:   h.color(e),
+		Caller:  c.callerstring(v),
+		Fields:  c.fieldstring(e, v),
+		Color:   c.color(e),
+		Entry:   e,
+		visitor: v,
 	}
 	h.Lock()
 	defer h.Unlock()
-	err = h.template.Execute(h.writer, d)
+	err = c.template.Execute(c.writer, d)
 	return err
 }
 
 // Data supplies log data to the template formatter for outputting into the log string. This
-// structure defines all the fields that can be used in the template.
+// structure defines all the fields that can be used in the template, Entry and FieldMap giving
+// access to the raw entry and its fields, e.g. {{.Entry.Time.Unix}} or {{index .FieldMap "key"}}.
 type Data struct {
-	Time    string
-	Level   string
-	Context string
-	Message string
-	Error   error
-	Caller  string
-	Fields  string
-	Color   int
+	Time     string
+	Level    string
+	Context  string
+	Message  string
+	Error    error
+	Caller   string
+	Fields   string
+	Color    int
+	Entry    slog.Entry
+	visitor  *visitor
+	fieldmap map[string]interface{}
 }
 
-func (h *Handler) contextstring(e slog.Entry) string {
-	return fmt.Sprint(e.Fields()[slog.ContextField])
+// FieldMap returns the fields of the entry, merged only when first requested.
+func (d *Data) FieldMap() map[string]interface{} {
+	if d.fieldmap == nil {
+		d.fieldmap = d.Entry.Fields()
+	}
+	return d.fieldmap
 }
 
-func (h *Handler) callerstring(e slog.Entry) string {
-	c, ok := e.Fields()[slog.CallerField]
-	if ok {
-		return fmt.Sprint(c)
+func (c *config) contextstring(v *visitor) string {
+	if context, ok := v.get(slog.ContextField); ok {
+		return context
 	}
-	return ""
+	return fmt.Sprint(nil)
+}
+
+func (c *config) callerstring(v *visitor) string {
+	caller, _ := v.get(slog.CallerField)
+	return caller
 }
 
-func (h *Handler) color(e slog.Entry) int {
-	c, ok := h.colors[e.Level()]
+func (c *config) color(e slog.Entry) int {
+	color, ok := c.colors[e.Level()]
 	if ok {
-		return c
+		return color
 	}
 	return gray
 }
 
-func (h *Handler) fieldstring(e slog.Entry) string {
+func (c *config) fieldstring(e slog.Entry, v *visitor) string {
 	fs := []field{}
-	for key, value := range e.Fields() {
-		if key == slog.ContextField && strings.Contains(h.templateStr, "{{.Context}}") {
+	for _, f := range v.fields {
+		// also when used in a pipeline, e.g. {{.Context | shortContext}}
+		if f.key == slog.ContextField && strings.Contains(c.templateStr, ".Context") {
 			continue
 		}
-		if key == slog.CallerField && strings.Contains(h.templateStr, "{{.Caller}}") {
+		if f.key == slog.CallerField && strings.Contains(c.templateStr, ".Caller") {
 			continue
 		}
-		fs = append(fs, field{key, value})
+		fs = append(fs, f)
 	}
-	if e.Error() != nil && !strings.Contains(h.templateStr, "{{.Error}}") {
+	if e.Error() != nil && !strings.Contains(c.templateStr, ".Error") {
 		fs = append(fs, field{slog.ErrorField, e.Error().Error()})
 	}
 
@@ -172,14 +350,14 @@ func (h *Handler) fieldstring(e slog.Entry) string {
 
 	res := []string{}
 	for _, f := range fs {
-		res = append(res, fmt.Sprintf("%s=%v", f.key, f.value))
+		res = append(res, f.key+"="+f.value)
 	}
 	return strings.Join(res, "; ")
 }
 
 type field struct {
 	key   string
-	value interface{}
+	value string
 }
 
 type sortablefields []field
@@ -195,3 +373,66 @@ func (sf sortablefields) Swap(i, j int) {
 func (sf sortablefields) Less(i, j int) bool {
 	return sf[i].key < sf[j].key
 }
+
+// visitor formats the visited fields into strings, typed fields without reflection.
+type visitor struct {
+	fields []field
+}
+
+func (v *visitor) get(key string) (string, bool) {
+	for _, f := range v.fields {
+		if f.key == key {
+			return f.value, true
+		}
+	}
+	return "", false
+}
+
+func (v *visitor) add(key string, value string) {
+	v.fields = append(v.fields, field{key, value})
+}
+
+func (v *visitor) VisitString(key string, value string) {
+	v.add(key, value)
+}
+
+func (v *visitor) VisitInt64(key string, value int64) {
+	v.add(key, strconv.FormatInt(value, 10))
+}
+
+func (v *visitor) VisitFloat64(key string, value float64) {
+	v.add(key, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (v *visitor) VisitBool(key string, value bool) {
+	v.add(key, strconv.FormatBool(value))
+}
+
+func (v *visitor) VisitDuration(key string, value time.Duration) {
+	v.add(key, value.String())
+}
+
+func (v *visitor) VisitTime(key string, value time.Time) {
+	v.add(key, value.String())
+}
+
+func (v *visitor) VisitError(key string, value error) {
+	v.add(key, value.Error())
+}
+
+// VisitObject outputs object and array marshalers under dotted keys, other values formatted
+// with fmt.
+func (v *visitor) VisitObject(key string, value interface{}) {
+	var err error
+	switch m := value.(type) {
+	case slog.ObjectMarshaler:
+		err = slog.FlattenObject(key, m, v)
+	case slog.ArrayMarshaler:
+		err = slog.FlattenArray(key, m, v)
+	default:
+		v.add(key, fmt.Sprint(value))
+	}
+	if err != nil {
+		v.add(key, fmt.Sprintf("!(ERROR=%v)", err))
+	}
+}
@@ -43,18 +43,19 @@ func TestHandler_basicOperation_success(t *testing.T) {
 func TestHandler_basicOperation_termTemplate_success(t *testing.T) {
 	lf := slog.New()
 	h := basic.New()
+	h.SetColorMode(basic.ColorAlways)
 	wr := &stringwriter{}
 	h.SetWriter(wr)
 	lf.AddEntryHandler(h)
 	lf.SetConcurrent(false)
 
 	lf.WithContext("test").WithField("A", 24).Info("done1")
-	// XXX the check below assumes next line is No 53
+	// XXX the check below assumes next line is No 54
 	lf.WithContext("test").WithFields(slf.Fields{"B": 26, "A": 25}).WithCaller(slf.CallerShort).WithError(errors.New("some error")).Warn("done2")
 	if !strings.Contains(wr.res, " [\033[32mINFO\033[0m] test: done1 A=24\n") {
 		t.Errorf("no match, %v", wr.res)
 	}
-	if !strings.Contains(wr.res, " [\033[33mWARN\033[0m] test (handler_test.go:53): done2 (\033[31merror: some error\033[0m) A=25; B=26\n") {
+	if !strings.Contains(wr.res, " [\033[33mWARN\033[0m] test (handler_test.go:54): done2 (\033[31merror: some error\033[0m) A=25; B=26\n") {
 		t.Errorf("no match, %v", wr.res)
 	}
 }
@@ -85,6 +86,7 @@ func TestHandler_timeformat_success(t *testing.T) {
 func TestHandler_setColors_success(t *testing.T) {
 	lf := slog.New()
 	h := basic.New()
+	h.SetColorMode(basic.ColorAlways)
 	cols := make(map[slf.Level]int)
 	cols[slf.LevelDebug] = 32
 	h.SetColors(cols)
@@ -160,3 +162,61 @@ func TestHandler_onPanic_error(t *testing.T) {
 		t.Errorf("error expected, %v", err)
 	}
 }
+
+func TestHandler_typedFields_success(t *testing.T) {
+	lf := slog.New()
+	h := basic.New()
+	if err := h.SetTemplate("[{{.Level}}] {{.Context}}: {{.Message}} {{.Fields}}"); err != nil {
+		t.Error(err)
+	}
+	wr := &stringwriter{}
+	h.SetWriter(wr)
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	lf.WithContext("test").(slog.StructuredLogger).WithTypedFields(
+		slog.String("s", "str"),
+		slog.Int64("i", 24),
+		slog.Float64("f", 2.5),
+		slog.Bool("b", false),
+		slog.Duration("d", time.Second),
+		slog.Err(errors.New("boom")),
+	).Info("done1")
+	if wr.res != "[INFO] test: done1 b=false; d=1s; error=boom; f=2.5; i=24; s=str\n" {
+		t.Errorf("no match, %v", wr.res)
+	}
+}
+
+type account struct {
+	name  string
+	roles []string
+}
+
+func (a account) MarshalLogObject(enc slog.ObjectEncoder) error {
+	enc.AddString("name", a.name)
+	return enc.AddArray("roles", slog.ArrayMarshalerFunc(func(arr slog.ArrayEncoder) error {
+		for _, role := range a.roles {
+			arr.AppendString(role)
+		}
+		return nil
+	}))
+}
+
+func TestHandler_objectMarshaler_dottedKeys_success(t *testing.T) {
+	lf := slog.New()
+	h := basic.New()
+	if err := h.SetTemplate("[{{.Level}}] {{.Context}}: {{.Message}} {{.Fields}}"); err != nil {
+		t.Error(err)
+	}
+	wr := &stringwriter{}
+	h.SetWriter(wr)
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	lf.WithContext("test").WithField("user", account{name: "jdoe", roles: []string{"dev", "ops"}}).WithField("bad", slog.ObjectMarshalerFunc(func(enc slog.ObjectEncoder) error {
+		return errors.New("boom")
+	})).Info("done1")
+	if wr.res != "[INFO] test: done1 bad=!(ERROR=boom); user.name=jdoe; user.roles.0=dev; user.roles.1=ops\n" {
+		t.Errorf("no match, %v", wr.res)
+	}
+}
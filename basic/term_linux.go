@@ -0,0 +1,27 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+//go:build linux
+// +build linux
+
+package basic
+
+import (
+	"io"
+	"syscall"
+	"unsafe"
+)
+
+// IsTerminal reports whether the writer is a file descriptor of a terminal, detected by
+// requesting its terminal attributes.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(interface {
+		Fd() uintptr
+	})
+	if !ok {
+		return false
+	}
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+//go:build !linux
+// +build !linux
+
+package basic
+
+import (
+	"io"
+	"os"
+)
+
+// IsTerminal reports whether the writer is a file of a character device, assumed to be a
+// terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"github.com/ventu-io/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type voidhandler struct{}
+
+func (voidhandler) Handle(e slog.Entry) error {
+	return nil
+}
+
+func voidhandlers(n int) []slog.EntryHandler {
+	res := make([]slog.EntryHandler, n)
+	for i := range res {
+		res[i] = voidhandler{}
+	}
+	return res
+}
+
+// BenchmarkLogger_handlers_parallel logs from all procs into many handlers, handled sequentially
+// or concurrently, for comparison with benchstat across revisions.
+func BenchmarkLogger_handlers_parallel(b *testing.B) {
+	for _, concurrent := range []bool{false, true} {
+		mode := "sequential"
+		if concurrent {
+			mode = "concurrent"
+		}
+		for _, n := range []int{1, 8, 32} {
+			b.Run(mode+"/"+strconv.Itoa(n), func(b *testing.B) {
+				lf := slog.New()
+				lf.SetEntryHandlers(voidhandlers(n)...)
+				lf.SetConcurrent(concurrent)
+				logger := lf.WithContext("bench")
+				b.ReportAllocs()
+				b.SetParallelism(8)
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						logger.Info("bench")
+					}
+				})
+				// concurrently handled entries are part of the work
+				lf.Flush()
+			})
+		}
+	}
+}
+
+// BenchmarkHandlerSnapshot compares obtaining the handlers to pass an entry to by copying the
+// slice under a read lock, as done previously, with loading an immutable slice atomically, for
+// entries handled sequentially or concurrently.
+func BenchmarkHandlerSnapshot(b *testing.B) {
+	handlers := voidhandlers(32)
+	var mu sync.RWMutex
+	copied := func() []slog.EntryHandler {
+		mu.RLock()
+		defer mu.RUnlock()
+		res := make([]slog.EntryHandler, len(handlers))
+		copy(res, handlers)
+		return res
+	}
+	var v atomic.Value
+	v.Store(handlers)
+	loaded := func() []slog.EntryHandler {
+		return v.Load().([]slog.EntryHandler)
+	}
+	for _, concurrent := range []bool{false, true} {
+		mode := "sequential"
+		if concurrent {
+			mode = "concurrent"
+		}
+		for i, snapshot := range []func() []slog.EntryHandler{copied, loaded} {
+			snapshot := snapshot
+			b.Run(mode+"/"+[]string{"rwmutex-copy", "atomic"}[i], func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetParallelism(8)
+				b.RunParallel(func(pb *testing.PB) {
+					var wg sync.WaitGroup
+					for pb.Next() {
+						for _, h := range snapshot() {
+							if !concurrent {
+								h.Handle(nil)
+								continue
+							}
+							wg.Add(1)
+							go func(h slog.EntryHandler) {
+								defer wg.Done()
+								h.Handle(nil)
+							}(h)
+						}
+					}
+					wg.Wait()
+				})
+			})
+		}
+	}
+}
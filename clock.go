@@ -0,0 +1,93 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"fmt"
+	"github.com/ventu-io/slf"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock supplies the time stamps of log entries and the time to measure trace and span durations
+// by. A log factory uses the system clock unless set otherwise with SetClock, e.g. to a fake clock
+// in tests (see package slogtest).
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to use a function as a Clock.
+type ClockFunc func() time.Time
+
+// Now implements the Clock interface.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock represents the clock of the system, used by log factories by default.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// ClockOf returns the clock of the log factory of the logger, e.g. for middleware to measure
+// durations consistently with traces, or the system clock for loggers of other implementations.
+func ClockOf(log slf.StructuredLogger) Clock {
+	if l, ok := log.(*logger); ok {
+		return l.rootLogger.factory.clock.Load().(clockholder).Clock
+	}
+	return SystemClock
+}
+
+// clockholder wraps clocks of any type to be stored in an atomic.Value.
+type clockholder struct {
+	Clock
+}
+
+// CoarseClock represents a clock reading the system time on every tick of a ticker only, for
+// services logging at high rates where the cost of reading the system clock for every entry
+// matters more than its precision. Readings retain the monotonic clock, durations measured by it
+// are however only as precise as the resolution.
+type CoarseClock struct {
+	// time.Time, atomic
+	now     atomic.Value
+	stop    chan bool
+	stopped chan bool
+	once    sync.Once
+}
+
+// NewCoarseClock constructs a coarse clock updated at the given resolution until stopped. A
+// non-positive resolution is rejected.
+func NewCoarseClock(resolution time.Duration) (*CoarseClock, error) {
+	if resolution <= 0 {
+		return nil, fmt.Errorf("slog: invalid clock resolution %v", resolution)
+	}
+	res := &CoarseClock{stop: make(chan bool), stopped: make(chan bool)}
+	res.now.Store(time.Now())
+	ticker := time.NewTicker(resolution)
+	go func() {
+		defer close(res.stopped)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				res.now.Store(now)
+			case <-res.stop:
+				return
+			}
+		}
+	}()
+	return res, nil
+}
+
+// Now implements the Clock interface.
+func (c *CoarseClock) Now() time.Time {
+	return c.now.Load().(time.Time)
+}
+
+// Stop stops updating the clock, which then shows the time of the last update.
+func (c *CoarseClock) Stop() {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	<-c.stopped
+}
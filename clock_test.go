@@ -0,0 +1,139 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"github.com/ventu-io/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoarseClock_success(t *testing.T) {
+	clock, err := slog.NewCoarseClock(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clock.Stop()
+	first := clock.Now()
+	if time.Since(first) > time.Minute {
+		t.Errorf("expected current time, %v", first)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !clock.Now().After(first) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the clock to advance")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Stop()
+	clock.Stop()
+	stopped := clock.Now()
+	time.Sleep(10 * time.Millisecond)
+	if !clock.Now().Equal(stopped) {
+		t.Error("expected the clock to stop")
+	}
+}
+
+func TestCoarseClock_factory_success(t *testing.T) {
+	clock, err := slog.NewCoarseClock(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clock.Stop()
+	th := &testhandler{}
+	lf := slog.New()
+	lf.SetClock(clock)
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	lf.WithContext("ctx").Info("first")
+	lf.WithContext("ctx").Info("second")
+	if len(th.entries) != 2 || !th.entries[0].Time().Equal(clock.Now()) || !th.entries[1].Time().Equal(clock.Now()) {
+		t.Errorf("expected the time stamps of the clock, %v", th.entries)
+	}
+}
+
+func TestCoarseClock_nonPositiveResolution_error(t *testing.T) {
+	for _, resolution := range []time.Duration{0, -time.Millisecond} {
+		if clock, err := slog.NewCoarseClock(resolution); err == nil || clock != nil {
+			t.Errorf("expected error for %v", resolution)
+		}
+	}
+}
+
+// backwardclock moves back by a second on every other reading.
+type backwardclock struct {
+	sync.Mutex
+	now time.Time
+	n   int
+}
+
+func (c *backwardclock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	c.n++
+	if c.n%2 == 0 {
+		c.now = c.now.Add(-time.Second)
+	} else {
+		c.now = c.now.Add(3 * time.Second)
+	}
+	return c.now
+}
+
+func TestLogger_timestamps_nonDecreasing_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.SetClock(&backwardclock{now: time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)})
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	logger := lf.WithContext("ctx")
+	for i := 0; i < 10; i++ {
+		logger.Info("entry")
+	}
+	for i := 1; i < len(th.entries); i++ {
+		if th.entries[i].Time().Before(th.entries[i-1].Time()) {
+			t.Errorf("%v: time stamp decreased, %v after %v", i, th.entries[i].Time(), th.entries[i-1].Time())
+		}
+	}
+	if last := th.entries[len(th.entries)-1].Time(); last.Location() != time.UTC {
+		t.Errorf("expected the location of the clock, %v", last.Location())
+	}
+}
+
+func TestLogger_timestamps_explicitTimesExempt_success(t *testing.T) {
+	th := &testhandler{}
+	start := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	lf := slog.New()
+	lf.SetClock(&backwardclock{now: start})
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	events := slog.Events(lf.WithContext("ctx"))
+	past, future := start.Add(-time.Hour), start.Add(time.Hour)
+	for i := 0; i < 5; i++ {
+		events.Info().Msg("entry")
+		events.Info().At(past).Msg("past")
+		events.Info().At(future).Msg("future")
+	}
+	var last time.Time
+	for i, e := range th.entries {
+		switch e.Message() {
+		case "past":
+			if !e.Time().Equal(past) {
+				t.Errorf("%v: expected explicit time, %v", i, e.Time())
+			}
+		case "future":
+			if !e.Time().Equal(future) {
+				t.Errorf("%v: expected explicit time, %v", i, e.Time())
+			}
+		default:
+			if e.Time().Before(last) || !e.Time().Before(future) {
+				t.Errorf("%v: unexpected time stamp, %v after %v", i, e.Time(), last)
+			}
+			last = e.Time()
+		}
+	}
+}
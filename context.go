@@ -0,0 +1,50 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"context"
+	"github.com/ventu-io/slf"
+)
+
+type loggerkey struct{}
+
+type fieldskey struct{}
+
+// ContextExtractor extracts fields, e.g. request or trace identifiers, from a context.Context.
+// Extractors registered with the log factory are applied by StructuredLogger.WithCtx.
+type ContextExtractor func(ctx context.Context) slf.Fields
+
+// NewContext returns a copy of the context carrying the logger.
+func NewContext(ctx context.Context, logger slf.StructuredLogger) context.Context {
+	return context.WithValue(ctx, loggerkey{}, logger)
+}
+
+// FromContext returns the logger carried by the context or a no-op logger if there is none.
+func FromContext(ctx context.Context) slf.StructuredLogger {
+	if logger, ok := ctx.Value(loggerkey{}).(slf.StructuredLogger); ok {
+		return logger
+	}
+	return noop
+}
+
+// NewFieldsContext returns a copy of the context carrying the fields in addition to those carried
+// by the parent context. The fields are added to loggers by StructuredLogger.WithCtx.
+func NewFieldsContext(ctx context.Context, fields slf.Fields) context.Context {
+	parent := FieldsFromContext(ctx)
+	res := make(slf.Fields, len(parent)+len(fields))
+	for key, value := range parent {
+		res[key] = value
+	}
+	for key, value := range fields {
+		res[key] = value
+	}
+	return context.WithValue(ctx, fieldskey{}, res)
+}
+
+// FieldsFromContext returns the fields carried by the context, the result must not be modified.
+func FieldsFromContext(ctx context.Context) slf.Fields {
+	fields, _ := ctx.Value(fieldskey{}).(slf.Fields)
+	return fields
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"context"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"testing"
+)
+
+type requestkey struct{}
+
+func TestFromContext_success(t *testing.T) {
+	lf := slog.New()
+	logger := lf.WithContext("ctx").WithField("key", "value")
+	ctx := slog.NewContext(context.Background(), logger)
+	if slog.FromContext(ctx) != logger {
+		t.Error("expected the logger carried by the context")
+	}
+	if _, ok := slog.FromContext(context.Background()).(*slf.Noop); !ok {
+		t.Error("expected a noop logger without a logger in the context")
+	}
+}
+
+func TestFieldsFromContext_merges_success(t *testing.T) {
+	parent := slog.NewFieldsContext(context.Background(), slf.Fields{"a": 1, "b": 2})
+	child := slog.NewFieldsContext(parent, slf.Fields{"b": 3, "c": 4})
+	if fields := slog.FieldsFromContext(parent); len(fields) != 2 || fields["b"] != 2 {
+		t.Errorf("expected parent fields unchanged, %v", fields)
+	}
+	if fields := slog.FieldsFromContext(child); len(fields) != 3 || fields["a"] != 1 || fields["b"] != 3 || fields["c"] != 4 {
+		t.Errorf("unexpected child fields, %v", fields)
+	}
+	if fields := slog.FieldsFromContext(context.Background()); fields != nil {
+		t.Errorf("expected no fields, %v", fields)
+	}
+}
+
+func TestLogger_withCtx_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	lf.AddContextExtractor(func(ctx context.Context) slf.Fields {
+		if id, ok := ctx.Value(requestkey{}).(string); ok {
+			return slf.Fields{"request_id": id}
+		}
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), requestkey{}, "r-25")
+	ctx = slog.NewFieldsContext(ctx, slf.Fields{"user": "john", "typed": "untyped"})
+	logger, _ := lf.WithContext("ctx").(slog.StructuredLogger)
+	logger.WithTypedFields(slog.Int64("typed", 5)).WithCtx(ctx).Info("with context")
+	logger.WithCtx(context.Background()).Info("without")
+	if len(th.entries) != 2 {
+		t.Fatalf("expected 2 entries, %v", th.entries)
+	}
+	fields := th.entries[0].Fields()
+	if fields["request_id"] != "r-25" || fields["user"] != "john" || fields["typed"] != "untyped" {
+		t.Errorf("expected fields from context, %v", fields)
+	}
+	if fields := th.entries[1].Fields(); len(fields) != 1 {
+		t.Errorf("expected the context field only, %v", fields)
+	}
+}
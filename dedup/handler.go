@@ -0,0 +1,176 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+// Package dedup provides an entry handler wrapper for slog collapsing repeated identical entries.
+// The first entry of a kind is passed on to the wrapped handler immediately, identical entries
+// following within a time window are suppressed and reported by a single entry annotated with
+// the number of repetitions and the time stamps of the first and last occurrence once the window
+// closes or the handler is flushed.
+package dedup
+
+import (
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// StandardWindow represents the deduplication window used by default.
+	StandardWindow = time.Second
+
+	// RepeatedField defines the key for the number of suppressed repetitions of an entry.
+	RepeatedField = "repeated"
+
+	// FirstField defines the key for the time stamp of the first occurrence of a repeated entry.
+	FirstField = "first"
+
+	// LastField defines the key for the time stamp of the last occurrence of a repeated entry.
+	LastField = "last"
+)
+
+// Handler represents an entry handler wrapper suppressing entries identical to an entry passed
+// on within the window. Entries are identical if their level, context, message and error text
+// match. The handler synchronises internally and can be used concurrently.
+type Handler struct {
+	sync.Mutex
+	handler slog.EntryHandler
+	window  time.Duration
+	pending map[key]*record
+}
+
+type key struct {
+	level   slf.Level
+	context string
+	message string
+	err     string
+}
+
+type record struct {
+	last     slog.Entry
+	first    time.Time
+	repeated int
+	timer    *time.Timer
+}
+
+// New constructs a new deduplicating handler wrapping the given one.
+func New(handler slog.EntryHandler) *Handler {
+	return &Handler{
+		handler: handler,
+		window:  StandardWindow,
+		pending: make(map[key]*record),
+	}
+}
+
+// SetWindow defines the time window, starting at the first occurrence of an entry, within which
+// identical entries are suppressed (default: 1s).
+func (h *Handler) SetWindow(window time.Duration) {
+	h.Lock()
+	h.window = window
+	h.Unlock()
+}
+
+// Handle forwards the log entry to the wrapped handler unless an identical one was forwarded
+// within the current window.
+func (h *Handler) Handle(e slog.Entry) error {
+	k := keyof(e)
+	h.Lock()
+	if rec, ok := h.pending[k]; ok {
+		rec.repeated++
+		rec.last = e
+		h.Unlock()
+		return nil
+	}
+	rec := &record{last: e, first: e.Time()}
+	rec.timer = time.AfterFunc(h.window, func() { h.close(k, rec) })
+	h.pending[k] = rec
+	h.Unlock()
+	return h.handler.Handle(e)
+}
+
+// Flush closes all open windows outputting the entries for suppressed repetitions, and flushes
+// the wrapped handler if it implements slog.Flusher. All entries are output even if the wrapped
+// handler fails, returning the first error encountered.
+func (h *Handler) Flush() error {
+	h.Lock()
+	recs := make([]*record, 0, len(h.pending))
+	for _, rec := range h.pending {
+		rec.timer.Stop()
+		recs = append(recs, rec)
+	}
+	h.pending = make(map[key]*record)
+	h.Unlock()
+
+	sort.Sort(sortablerecords(recs))
+	var res error
+	for _, rec := range recs {
+		if rec.repeated == 0 {
+			continue
+		}
+		if err := h.handler.Handle(rec.entry()); err != nil && res == nil {
+			res = err
+		}
+	}
+	if f, ok := h.handler.(slog.Flusher); ok {
+		if err := f.Flush(); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+func (h *Handler) close(k key, rec *record) {
+	h.Lock()
+	if h.pending[k] != rec {
+		// already flushed
+		h.Unlock()
+		return
+	}
+	delete(h.pending, k)
+	h.Unlock()
+
+	if rec.repeated == 0 {
+		return
+	}
+	if err := h.handler.Handle(rec.entry()); err != nil {
+		// fall back to standard logging to output entry handler error
+		slog.ReportHandlerError(err)
+	}
+}
+
+func keyof(e slog.Entry) key {
+	k := key{level: e.Level(), message: e.Message()}
+	k.context, _ = e.Fields()[slog.ContextField].(string)
+	if e.Error() != nil {
+		k.err = e.Error().Error()
+	}
+	return k
+}
+
+// entry constructs the entry reporting the suppressed repetitions from the last one of them.
+func (rec *record) entry() slog.Entry {
+	e := rec.last
+	fields := make(map[string]interface{})
+	for key, value := range e.Fields() {
+		fields[key] = value
+	}
+	fields[RepeatedField] = rec.repeated
+	fields[FirstField] = rec.first
+	fields[LastField] = e.Time()
+	return slog.NewEntry(e.Time(), e.Level(), e.Message(), e.Error(), fields)
+}
+
+type sortablerecords []*record
+
+func (sr sortablerecords) Len() int {
+	return len(sr)
+}
+
+func (sr sortablerecords) Swap(i, j int) {
+	sr[i], sr[j] = sr[j], sr[i]
+}
+
+func (sr sortablerecords) Less(i, j int) bool {
+	return sr[i].first.Before(sr[j].first)
+}
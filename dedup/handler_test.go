@@ -0,0 +1,147 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package dedup_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/dedup"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testhandler struct {
+	sync.Mutex
+	entries []slog.Entry
+}
+
+func (th *testhandler) Handle(entry slog.Entry) error {
+	th.Lock()
+	th.entries = append(th.entries, entry)
+	th.Unlock()
+	return nil
+}
+
+func (th *testhandler) count() int {
+	th.Lock()
+	defer th.Unlock()
+	return len(th.entries)
+}
+
+func TestHandler_collapsesIdentical_onFlush_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(dedup.New(th))
+	lf.SetConcurrent(false)
+
+	err := errors.New("connection refused")
+	for i := 0; i < 10; i++ {
+		lf.WithContext("test").WithField("i", i).WithError(err).Warn("retrying")
+	}
+	if len(th.entries) != 1 {
+		t.Fatalf("expected first entry only, %v", th.entries)
+	}
+	if err := lf.Flush(); err != nil {
+		t.Error(err)
+	}
+	if len(th.entries) != 2 {
+		t.Fatalf("expected repeated entry, %v", th.entries)
+	}
+	first, last := th.entries[0], th.entries[1]
+	if last.Message() != "retrying" || last.Level() != slf.LevelWarn || last.Error().Error() != "connection refused" {
+		t.Errorf("unexpected entry, %v", last)
+	}
+	fields := last.Fields()
+	if fields[dedup.RepeatedField] != 9 || fields["i"] != 9 || fields[slog.ContextField] != "test" {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+	if fields[dedup.FirstField] != first.Time() || fields[dedup.LastField] != last.Time() {
+		t.Errorf("unexpected time stamps, %v", fields)
+	}
+	if _, ok := first.Fields()[dedup.RepeatedField]; ok {
+		t.Error("first entry must not be modified")
+	}
+}
+
+func TestHandler_distinguishesEntries_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(dedup.New(th))
+	lf.SetConcurrent(false)
+
+	for i := 0; i < 2; i++ {
+		lf.WithContext("test").Warn("msg")
+		lf.WithContext("test").Error("msg")
+		lf.WithContext("other").Warn("msg")
+		lf.WithContext("test").Warn("other")
+		lf.WithContext("test").WithError(errors.New("err")).Warn("msg")
+	}
+	if len(th.entries) != 5 {
+		t.Errorf("expected 5 distinct entries, %v", len(th.entries))
+	}
+	lf.Flush()
+	if len(th.entries) != 10 {
+		t.Errorf("expected 5 repeated entries, %v", len(th.entries))
+	}
+}
+
+func TestHandler_windowCloses_success(t *testing.T) {
+	th := &testhandler{}
+	h := dedup.New(th)
+	h.SetWindow(20 * time.Millisecond)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	lf.WithContext("test").Warn("single")
+	lf.WithContext("test").Warn("repeated")
+	lf.WithContext("test").Warn("repeated")
+	time.Sleep(100 * time.Millisecond)
+	if th.count() != 3 {
+		t.Errorf("expected single, repeated and its repetition, %v", th.entries)
+	}
+	lf.WithContext("test").Warn("repeated")
+	if th.count() != 4 {
+		t.Errorf("expected new window, %v", th.entries)
+	}
+	lf.Flush()
+	if th.count() != 4 {
+		t.Errorf("expected no repetitions, %v", th.entries)
+	}
+}
+
+type failinghandler struct {
+	testhandler
+	fail bool
+}
+
+func (fh *failinghandler) Handle(entry slog.Entry) error {
+	fh.testhandler.Handle(entry)
+	if fh.fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestHandler_flush_handlesAllOnError_error(t *testing.T) {
+	fh := &failinghandler{}
+	h := dedup.New(fh)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	for i := 0; i < 3; i++ {
+		lf.WithContext("test").Info("first")
+		lf.WithContext("test").Info("second")
+	}
+	fh.fail = true
+	if err := h.Flush(); err == nil || err.Error() != "failed" {
+		t.Errorf("expected error, %v", err)
+	}
+	if fh.count() != 4 {
+		t.Errorf("expected both repeated entries, %v", fh.entries)
+	}
+}
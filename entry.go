@@ -5,6 +5,7 @@ package slog
 
 import (
 	"github.com/ventu-io/slf"
+	"sync"
 	"time"
 )
 
@@ -16,6 +17,14 @@ type EntryHandler interface {
 	Handle(Entry) error
 }
 
+// Flusher is implemented by entry handlers that buffer or aggregate entries, e.g. handlers wrapping
+// other handlers. Flush is called by the log factory on LogFactory.Flush.
+type Flusher interface {
+
+	// Flush outputs any entries retained by the handler.
+	Flush() error
+}
+
 // Entry represents a log entry for structured logging. Entries are only created when the requested
 // level is same or above the minimum log level of the context root.
 type Entry interface {
@@ -36,12 +45,22 @@ type Entry interface {
 	Fields() map[string]interface{}
 }
 
+// NewEntry constructs a log entry from the given data, e.g. for entry handlers deriving new entries
+// from the ones they receive. The field map is used as is and must not be modified afterwards.
+func NewEntry(tm time.Time, level slf.Level, message string, err error, fields map[string]interface{}) Entry {
+	return &entry{tm: tm, level: level, message: message, err: err, fields: fields}
+}
+
 type entry struct {
 	tm      time.Time
 	level   slf.Level
 	message string
 	err     error
 	fields  map[string]interface{}
+	// typed fields, not present in fields, merged into the field map on first request only
+	typed  []Field
+	once   sync.Once
+	merged map[string]interface{}
 }
 
 func (e *entry) Time() time.Time {
@@ -61,5 +80,31 @@ func (e *entry) Error() error {
 }
 
 func (e *entry) Fields() map[string]interface{} {
-	return e.fields
+	if len(e.typed) == 0 {
+		return e.fields
+	}
+	e.once.Do(func() {
+		e.merged = make(map[string]interface{}, len(e.fields)+len(e.typed))
+		for key, value := range e.fields {
+			e.merged[key] = value
+		}
+		for _, f := range e.typed {
+			e.merged[f.Key] = f.Value()
+		}
+	})
+	return e.merged
+}
+
+// parts returns the field map without the typed fields and the typed fields.
+func (e *entry) parts() (map[string]interface{}, []Field) {
+	return e.fields, e.typed
+}
+
+func (e *entry) visitFields(v FieldVisitor) {
+	for key, value := range e.fields {
+		visit(key, value, v)
+	}
+	for _, f := range e.typed {
+		f.Accept(v)
+	}
 }
@@ -0,0 +1,219 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"fmt"
+	"github.com/ventu-io/slf"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var eventpool = sync.Pool{New: func() interface{} { return &Event{} }}
+
+// EventLogger delivers events, builders of single entries with typed fields, for a logger:
+//
+//	events := slog.Events(logger)
+//	events.Info().Str("user", u).Int("n", n).Err(err).Msg("done")
+type EventLogger struct {
+	log slf.StructuredLogger
+}
+
+// Events returns the event logger for the logger. Loggers not delivered by a slog log factory
+// are supported, but their events are neither pooled nor disabled by level.
+func Events(log slf.StructuredLogger) EventLogger {
+	return EventLogger{log: log}
+}
+
+// Debug starts an event at the debug level.
+func (el EventLogger) Debug() *Event {
+	return el.Level(slf.LevelDebug)
+}
+
+// Info starts an event at the info level.
+func (el EventLogger) Info() *Event {
+	return el.Level(slf.LevelInfo)
+}
+
+// Warn starts an event at the warn level.
+func (el EventLogger) Warn() *Event {
+	return el.Level(slf.LevelWarn)
+}
+
+// Error starts an event at the error level.
+func (el EventLogger) Error() *Event {
+	return el.Level(slf.LevelError)
+}
+
+// Level starts an event at the given level. If the level is disabled the event is nil, on which
+// all methods are no-ops, so that building it does not allocate.
+func (el EventLogger) Level(level slf.Level) *Event {
+	if log, ok := el.log.(*logger); ok {
+		if level < log.rootLogger.level() {
+			return nil
+		}
+		e := eventpool.Get().(*Event)
+		e.log, e.level, e.err = log, level, log.err
+		return e
+	}
+	return &Event{other: el.log, level: level}
+}
+
+// Event builds a single entry with typed fields and sends it to the entry handlers on Msg, Msgf
+// or Send, after which it must not be used. Events of slog loggers are pooled and the entry they
+// produce is the same as one logged with WithTypedFields, fields of the event overriding those of
+// the logger. All methods accept a nil event doing nothing.
+type Event struct {
+	log    *logger
+	other  slf.StructuredLogger
+	level  slf.Level
+	err    error
+	at     time.Time
+	fields []Field
+}
+
+// Str adds a string field.
+func (e *Event) Str(key string, value string) *Event {
+	return e.Field(String(key, value))
+}
+
+// Int adds an int field.
+func (e *Event) Int(key string, value int) *Event {
+	return e.Field(Int64(key, int64(value)))
+}
+
+// Int64 adds an int64 field.
+func (e *Event) Int64(key string, value int64) *Event {
+	return e.Field(Int64(key, value))
+}
+
+// Float64 adds a float64 field.
+func (e *Event) Float64(key string, value float64) *Event {
+	return e.Field(Float64(key, value))
+}
+
+// Bool adds a bool field.
+func (e *Event) Bool(key string, value bool) *Event {
+	return e.Field(Bool(key, value))
+}
+
+// Dur adds a time.Duration field.
+func (e *Event) Dur(key string, value time.Duration) *Event {
+	return e.Field(Duration(key, value))
+}
+
+// Time adds a time.Time field.
+func (e *Event) Time(key string, value time.Time) *Event {
+	return e.Field(Time(key, value))
+}
+
+// Any adds a field with a value of any type.
+func (e *Event) Any(key string, value interface{}) *Event {
+	return e.Field(Object(key, value))
+}
+
+// Err sets the entry error as WithError does.
+func (e *Event) Err(err error) *Event {
+	if e != nil {
+		e.err = err
+	}
+	return e
+}
+
+// At sets the time stamp of the entry, e.g. of an entry recorded elsewhere, in place of the time
+// by the factory clock. Such entries are exempt from time stamps never decreasing within the
+// context, and do not affect the stamps of other entries. It is ignored for loggers not delivered
+// by a slog log factory.
+func (e *Event) At(tm time.Time) *Event {
+	if e != nil {
+		e.at = tm
+	}
+	return e
+}
+
+// Field adds typed fields.
+func (e *Event) Field(fields ...Field) *Event {
+	if e != nil {
+		e.fields = append(e.fields, fields...)
+	}
+	return e
+}
+
+// Msg sends the entry with the message.
+func (e *Event) Msg(message string) {
+	if e != nil {
+		e.send(message)
+	}
+}
+
+// Msgf sends the entry with the formatted message.
+func (e *Event) Msgf(format string, args ...interface{}) {
+	if e != nil {
+		e.send(fmt.Sprintf(format, args...))
+	}
+}
+
+// Send sends the entry with an empty message.
+func (e *Event) Send() {
+	if e != nil {
+		e.send("")
+	}
+}
+
+func (e *Event) send(message string) {
+	if e.log == nil {
+		fields := make(slf.Fields, len(e.fields))
+		for _, f := range e.fields {
+			fields[f.Key] = f.Value()
+		}
+		log := e.other.WithFields(fields)
+		if e.err != nil {
+			log.WithError(e.err).Log(e.level, message)
+		} else {
+			log.Log(e.level, message)
+		}
+		return
+	}
+	log := e.log
+	// skip: entry, send, Msg/Msgf/Send
+	log.handleall(log.entry(e.level, message, 3, e.err, e.typed(), e.at))
+	atomic.StoreInt64(&log.lasttouch, log.rootLogger.factory.monotonic())
+	atomic.StoreInt32(&log.lastlevel, int32(e.level))
+	e.release()
+}
+
+// typed returns a new slice of the logger and event fields, of which the last one for every
+// key wins, as the event slice is reused and the entry may be handled asynchronously.
+func (e *Event) typed() []Field {
+	res := make([]Field, 0, len(e.log.typed)+len(e.fields))
+	for _, f := range e.log.typed {
+		if !overridden(f.Key, e.fields) {
+			res = append(res, f)
+		}
+	}
+	for i, f := range e.fields {
+		if !overridden(f.Key, e.fields[i+1:]) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func overridden(key string, fields []Field) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Event) release() {
+	for i := range e.fields {
+		e.fields[i] = Field{}
+	}
+	e.log, e.err, e.at, e.fields = nil, nil, time.Time{}, e.fields[:0]
+	eventpool.Put(e)
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type collector struct {
+	fields map[string]interface{}
+}
+
+func (c *collector) visit(key string, value interface{}) {
+	if _, ok := c.fields[key]; ok {
+		panic("duplicate field " + key)
+	}
+	c.fields[key] = value
+}
+
+func (c *collector) VisitString(key string, value string)          { c.visit(key, value) }
+func (c *collector) VisitInt64(key string, value int64)            { c.visit(key, value) }
+func (c *collector) VisitFloat64(key string, value float64)        { c.visit(key, value) }
+func (c *collector) VisitBool(key string, value bool)              { c.visit(key, value) }
+func (c *collector) VisitDuration(key string, value time.Duration) { c.visit(key, value) }
+func (c *collector) VisitTime(key string, value time.Time)         { c.visit(key, value) }
+func (c *collector) VisitError(key string, value error)            { c.visit(key, value) }
+func (c *collector) VisitObject(key string, value interface{})     { c.visit(key, value) }
+
+func TestEvent_fields_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	lf.SetCallerInfo(slf.CallerShort)
+	logger := lf.WithContext("ctx").WithField("a", "logger").WithField("b", 1)
+	logger = logger.(slog.StructuredLogger).WithTypedFields(slog.String("c", "typed"))
+
+	err := errors.New("failed")
+	tm := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	slog.Events(logger).Warn().Str("a", "event").Int("n", 5).Int64("i", 6).Float64("f", 1.5).
+		Bool("ok", true).Dur("d", time.Second).Time("t", tm).Any("o", []int{1}).
+		Str("c", "first").Str("c", "second").Err(err).Msg("done")
+
+	if len(th.entries) != 1 {
+		t.Fatalf("expected 1 entry, %v", th.entries)
+	}
+	e := th.entries[0]
+	if e.Level() != slf.LevelWarn || e.Message() != "done" || e.Error() != err {
+		t.Errorf("unexpected entry, %v %v %v", e.Level(), e.Message(), e.Error())
+	}
+	fields := e.Fields()
+	expected := map[string]interface{}{"a": "event", "b": 1, "c": "second", "n": int64(5), "i": int64(6),
+		"f": 1.5, "ok": true, "d": time.Second}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("%v: expected %v, found %v", key, value, fields[key])
+		}
+	}
+	if tt, _ := fields["t"].(time.Time); !tt.Equal(tm) {
+		t.Errorf("unexpected time, %v", fields["t"])
+	}
+	if caller, _ := fields[slog.CallerField].(string); !strings.HasPrefix(caller, "event_test.go:") {
+		t.Errorf("unexpected caller, %v", caller)
+	}
+	c := &collector{fields: make(map[string]interface{})}
+	slog.VisitFields(e, c)
+	if c.fields["c"] != "second" || c.fields["a"] != "event" || len(c.fields) != len(fields) {
+		t.Errorf("unexpected visited fields, %v", c.fields)
+	}
+}
+
+func TestEvent_reuse_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	events := slog.Events(lf.WithContext("ctx").WithError(errors.New("logger")).(slf.StructuredLogger))
+
+	for i := 0; i < 3; i++ {
+		events.Info().Int("i", i).Msgf("entry %v", i)
+	}
+	events.Error().Send()
+	if len(th.entries) != 4 {
+		t.Fatalf("expected 4 entries, %v", th.entries)
+	}
+	for i := 0; i < 3; i++ {
+		e := th.entries[i]
+		if e.Message() != "entry "+string(rune('0'+i)) || e.Fields()["i"] != int64(i) || len(e.Fields()) != 2 {
+			t.Errorf("unexpected entry, %v %v", e.Message(), e.Fields())
+		}
+		if e.Error() == nil || e.Error().Error() != "logger" {
+			t.Errorf("expected logger error, %v", e.Error())
+		}
+	}
+	if e := th.entries[3]; e.Level() != slf.LevelError || e.Message() != "" || len(e.Fields()) != 1 {
+		t.Errorf("unexpected entry, %v %v %v", e.Level(), e.Message(), e.Fields())
+	}
+}
+
+func TestEvent_disabledLevel_zeroAllocs_success(t *testing.T) {
+	events := slog.Events(disabledlogger())
+	if events.Debug() != nil {
+		t.Error("expected nil event")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		events.Debug().Str("user", "u").Int("n", 1).Err(nil).Msg("done")
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, %v", allocs)
+	}
+}
+
+func TestEvent_otherLogger_success(t *testing.T) {
+	// no entry handlers are required for a noop logger
+	slog.Events(&slf.Noop{}).Info().Str("key", "value").Err(errors.New("err")).Msg("done")
+	slog.Events(&slf.Noop{}).Debug().Send()
+}
+
+func TestEvent_at_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	events := slog.Events(lf.WithContext("ctx"))
+
+	tm := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	events.Info().At(tm).Msg("at")
+	before := time.Now()
+	events.Info().Msg("now")
+	if len(th.entries) != 2 || !th.entries[0].Time().Equal(tm) || th.entries[1].Time().Before(before) {
+		t.Errorf("unexpected time stamps, %v", th.entries)
+	}
+}
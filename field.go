@@ -0,0 +1,217 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"math"
+	"time"
+)
+
+// range of times representable by UnixNano
+var (
+	minnanotime = time.Unix(0, math.MinInt64)
+	maxnanotime = time.Unix(0, math.MaxInt64)
+)
+
+// FieldType identifies the type of the value stored in a typed field.
+type FieldType uint8
+
+const (
+	// UnknownType identifies an empty field.
+	UnknownType FieldType = iota
+
+	// StringType identifies a string value.
+	StringType
+
+	// Int64Type identifies an int64 value.
+	Int64Type
+
+	// Float64Type identifies a float64 value.
+	Float64Type
+
+	// BoolType identifies a bool value.
+	BoolType
+
+	// DurationType identifies a time.Duration value.
+	DurationType
+
+	// TimeType identifies a time.Time value.
+	TimeType
+
+	// ErrorType identifies an error value.
+	ErrorType
+
+	// ObjectType identifies a value of any other type.
+	ObjectType
+)
+
+// Field represents a typed field storing its value without boxing it into an interface{}, except
+// for errors and objects. Fields are constructed with String, Int64, Float64 etc. and added to
+// loggers with WithTypedFields. Handlers read them via VisitFields without resorting to reflection.
+type Field struct {
+	Key  string
+	Type FieldType
+	// the value by type, read via Value or Accept
+	integer int64
+	str     string
+	object  interface{}
+}
+
+// FieldVisitor receives the fields of a log entry with their values in their original types.
+type FieldVisitor interface {
+	VisitString(key string, value string)
+	VisitInt64(key string, value int64)
+	VisitFloat64(key string, value float64)
+	VisitBool(key string, value bool)
+	VisitDuration(key string, value time.Duration)
+	VisitTime(key string, value time.Time)
+	VisitError(key string, value error)
+	VisitObject(key string, value interface{})
+}
+
+// String constructs a field with a string value.
+func String(key string, value string) Field {
+	return Field{Key: key, Type: StringType, str: value}
+}
+
+// Int64 constructs a field with an int64 value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Type: Int64Type, integer: value}
+}
+
+// Float64 constructs a field with a float64 value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Type: Float64Type, integer: int64(math.Float64bits(value))}
+}
+
+// Bool constructs a field with a bool value.
+func Bool(key string, value bool) Field {
+	f := Field{Key: key, Type: BoolType}
+	if value {
+		f.integer = 1
+	}
+	return f
+}
+
+// Duration constructs a field with a time.Duration value.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Type: DurationType, integer: int64(value)}
+}
+
+// Time constructs a field with a time.Time value (the monotonic clock reading is not retained).
+// Times outside the range of UnixNano, e.g. the zero time, are boxed.
+func Time(key string, value time.Time) Field {
+	if value.Before(minnanotime) || value.After(maxnanotime) {
+		return Field{Key: key, Type: TimeType, object: value.Round(0)}
+	}
+	return Field{Key: key, Type: TimeType, integer: value.UnixNano(), object: value.Location()}
+}
+
+// Err constructs a field with an error value under the ErrorField key.
+func Err(err error) Field {
+	return NamedErr(ErrorField, err)
+}
+
+// NamedErr constructs a field with an error value under the given key.
+func NamedErr(key string, err error) Field {
+	return Field{Key: key, Type: ErrorType, object: err}
+}
+
+// Object constructs a field with a value of any type.
+func Object(key string, value interface{}) Field {
+	return Field{Key: key, Type: ObjectType, object: value}
+}
+
+// Value returns the field value as an interface{}.
+func (f Field) Value() interface{} {
+	switch f.Type {
+	case StringType:
+		return f.str
+	case Int64Type:
+		return f.integer
+	case Float64Type:
+		return math.Float64frombits(uint64(f.integer))
+	case BoolType:
+		return f.integer == 1
+	case DurationType:
+		return time.Duration(f.integer)
+	case TimeType:
+		return f.time()
+	}
+	return f.object
+}
+
+// Accept passes the field to the visitor method matching its type.
+func (f Field) Accept(v FieldVisitor) {
+	switch f.Type {
+	case StringType:
+		v.VisitString(f.Key, f.str)
+	case Int64Type:
+		v.VisitInt64(f.Key, f.integer)
+	case Float64Type:
+		v.VisitFloat64(f.Key, math.Float64frombits(uint64(f.integer)))
+	case BoolType:
+		v.VisitBool(f.Key, f.integer == 1)
+	case DurationType:
+		v.VisitDuration(f.Key, time.Duration(f.integer))
+	case TimeType:
+		v.VisitTime(f.Key, f.time())
+	case ErrorType:
+		if err, ok := f.object.(error); ok {
+			v.VisitError(f.Key, err)
+		} else {
+			v.VisitObject(f.Key, nil)
+		}
+	default:
+		v.VisitObject(f.Key, f.object)
+	}
+}
+
+func (f Field) time() time.Time {
+	switch v := f.object.(type) {
+	case time.Time:
+		return v
+	case *time.Location:
+		return time.Unix(0, f.integer).In(v)
+	}
+	return time.Unix(0, f.integer)
+}
+
+// VisitFields passes every field of the log entry to the visitor method matching the type of its
+// value, in no particular order. Typed fields are visited without boxing their values, values in
+// the field map of other types than those of the visitor methods are passed to VisitObject.
+func VisitFields(e Entry, v FieldVisitor) {
+	if te, ok := e.(interface {
+		visitFields(FieldVisitor)
+	}); ok {
+		te.visitFields(v)
+		return
+	}
+	for key, value := range e.Fields() {
+		visit(key, value, v)
+	}
+}
+
+func visit(key string, value interface{}, v FieldVisitor) {
+	switch val := value.(type) {
+	case string:
+		v.VisitString(key, val)
+	case int:
+		v.VisitInt64(key, int64(val))
+	case int64:
+		v.VisitInt64(key, val)
+	case float64:
+		v.VisitFloat64(key, val)
+	case bool:
+		v.VisitBool(key, val)
+	case time.Duration:
+		v.VisitDuration(key, val)
+	case time.Time:
+		v.VisitTime(key, val)
+	case error:
+		v.VisitError(key, val)
+	default:
+		v.VisitObject(key, value)
+	}
+}
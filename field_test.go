@@ -0,0 +1,182 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ventu-io/slog"
+	"testing"
+	"time"
+)
+
+type recordingvisitor struct {
+	visited map[string]string
+}
+
+func (v *recordingvisitor) VisitString(key string, value string) {
+	v.visited[key] = "string:" + value
+}
+
+func (v *recordingvisitor) VisitInt64(key string, value int64) {
+	v.visited[key] = fmt.Sprintf("int64:%v", value)
+}
+
+func (v *recordingvisitor) VisitFloat64(key string, value float64) {
+	v.visited[key] = fmt.Sprintf("float64:%v", value)
+}
+
+func (v *recordingvisitor) VisitBool(key string, value bool) {
+	v.visited[key] = fmt.Sprintf("bool:%v", value)
+}
+
+func (v *recordingvisitor) VisitDuration(key string, value time.Duration) {
+	v.visited[key] = fmt.Sprintf("duration:%v", value)
+}
+
+func (v *recordingvisitor) VisitTime(key string, value time.Time) {
+	v.visited[key] = fmt.Sprintf("time:%v", value.UTC().Format(time.RFC3339))
+}
+
+func (v *recordingvisitor) VisitError(key string, value error) {
+	v.visited[key] = fmt.Sprintf("error:%v", value)
+}
+
+func (v *recordingvisitor) VisitObject(key string, value interface{}) {
+	v.visited[key] = fmt.Sprintf("object:%v", value)
+}
+
+func TestTypedFields_visitedWithTypes_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	tm := time.Date(2016, 3, 26, 17, 41, 14, 0, time.UTC)
+	logger := lf.WithContext("test").(slog.StructuredLogger)
+	logger.WithField("untyped", 5).(slog.StructuredLogger).WithTypedFields(
+		slog.String("s", "str"),
+		slog.Int64("i", -3),
+		slog.Float64("f", 2.5),
+		slog.Bool("b", true),
+		slog.Duration("d", time.Second),
+		slog.Time("t", tm),
+		slog.Err(errors.New("err")),
+		slog.Object("o", []int{1, 2}),
+	).Info("typed")
+
+	v := &recordingvisitor{visited: make(map[string]string)}
+	slog.VisitFields(th.entries[0], v)
+	expected := map[string]string{
+		slog.ContextField: "string:test",
+		"untyped":         "int64:5",
+		"s":               "string:str",
+		"i":               "int64:-3",
+		"f":               "float64:2.5",
+		"b":               "bool:true",
+		"d":               "duration:1s",
+		"t":               "time:2016-03-26T17:41:14Z",
+		slog.ErrorField:   "error:err",
+		"o":               "object:[1 2]",
+	}
+	if len(v.visited) != len(expected) {
+		t.Errorf("unexpected fields, %v", v.visited)
+	}
+	for key, value := range expected {
+		if v.visited[key] != value {
+			t.Errorf("unexpected value for %v, %v", key, v.visited[key])
+		}
+	}
+}
+
+func TestTypedFields_mergedIntoFieldMap_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	tm := time.Now()
+	logger := lf.WithContext("test").(slog.StructuredLogger)
+	logger.WithTypedFields(slog.Int64("i", 3), slog.Time("t", tm), slog.Bool("b", false), slog.Float64("f", 0.5)).Info("typed")
+	fields := th.entries[0].Fields()
+	if fields["i"] != int64(3) || fields["b"] != false || fields["f"] != 0.5 || fields[slog.ContextField] != "test" {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+	if ftm, ok := fields["t"].(time.Time); !ok || !ftm.Equal(tm) {
+		t.Errorf("unexpected time, %v", fields["t"])
+	}
+}
+
+func TestTypedFields_laterFieldsOverride_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	logger := lf.WithContext("test").WithField("a", "untyped").(slog.StructuredLogger)
+	logger0 := logger.WithTypedFields(slog.Int64("a", 1), slog.Int64("b", 1))
+	logger1 := logger0.WithTypedFields(slog.Int64("b", 2))
+	logger2 := logger1.WithField("a", "untyped again")
+	logger0.Info("info0")
+	logger1.Info("info1")
+	logger2.Info("info2")
+
+	expected := []string{
+		"map[a:int64:1 b:int64:1 context:string:test]",
+		"map[a:int64:1 b:int64:2 context:string:test]",
+		"map[a:string:untyped again b:int64:2 context:string:test]",
+	}
+	for i, e := range th.entries {
+		v := &recordingvisitor{visited: make(map[string]string)}
+		slog.VisitFields(e, v)
+		if fmt.Sprint(v.visited) != expected[i] {
+			t.Errorf("unexpected fields, %v", v.visited)
+		}
+	}
+}
+
+func TestVisitFields_derivedEntry_success(t *testing.T) {
+	d := slog.Derive(slog.NewEntry(time.Now(), 0, "msg", nil, map[string]interface{}{"a": "b", "n": 2.5}))
+	v := &recordingvisitor{visited: make(map[string]string)}
+	slog.VisitFields(d, v)
+	if v.visited["a"] != "string:b" || v.visited["n"] != "float64:2.5" {
+		t.Errorf("unexpected fields, %v", v.visited)
+	}
+}
+
+type timevisitor struct {
+	tm time.Time
+}
+
+func (v *timevisitor) VisitString(key string, value string)          {}
+func (v *timevisitor) VisitInt64(key string, value int64)            {}
+func (v *timevisitor) VisitFloat64(key string, value float64)        {}
+func (v *timevisitor) VisitBool(key string, value bool)              {}
+func (v *timevisitor) VisitDuration(key string, value time.Duration) {}
+func (v *timevisitor) VisitTime(key string, value time.Time)         { v.tm = value }
+func (v *timevisitor) VisitError(key string, value error)            {}
+func (v *timevisitor) VisitObject(key string, value interface{})     {}
+
+func TestTypedFields_time_roundTrips_success(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	for _, tm := range []time.Time{
+		{},
+		time.Date(2016, 1, 2, 3, 4, 5, 6, loc),
+		time.Date(1600, 1, 2, 3, 4, 5, 6, time.UTC),
+		time.Date(3000, 1, 2, 3, 4, 5, 6, loc),
+	} {
+		f := slog.Time("t", tm)
+		if value, _ := f.Value().(time.Time); !value.Equal(tm) || value.Location().String() != tm.Location().String() {
+			t.Errorf("expected %v, found %v", tm, f.Value())
+		}
+		v := &timevisitor{}
+		f.Accept(v)
+		if !v.tm.Equal(tm) {
+			t.Errorf("expected %v visited, found %v", tm, v.tm)
+		}
+	}
+	if tm, _ := slog.Time("t", time.Time{}).Value().(time.Time); !tm.IsZero() {
+		t.Errorf("expected zero time, %v", tm)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+// Package httplog provides a net/http middleware logging an access log entry per request through
+// a slog logger and placing a per-request logger carrying the request ID into the request context.
+package httplog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"net"
+	"net/http"
+)
+
+const (
+	// StandardRequestIDHeader represents the request and response header of the request ID used
+	// by default.
+	StandardRequestIDHeader = "X-Request-ID"
+
+	// RequestIDField defines the key for the request ID.
+	RequestIDField = "request_id"
+
+	// MethodField defines the key for the request method.
+	MethodField = "method"
+
+	// PathField defines the key for the request URL path.
+	PathField = "path"
+
+	// StatusField defines the key for the response status code.
+	StatusField = "status"
+
+	// BytesField defines the key for the number of bytes of the response body.
+	BytesField = "bytes"
+
+	// RemoteAddrField defines the key for the remote address of the request.
+	RemoteAddrField = "remote_addr"
+
+	// UserAgentField defines the key for the user agent of the request.
+	UserAgentField = "user_agent"
+
+	maxRequestID      = 128
+	accessMessage     = "request"
+	traceparentHeader = "Traceparent"
+)
+
+// Handler represents an http.Handler middleware logging an access log entry for every request
+// handled by the wrapped handler with the method, path, status, bytes, duration (under the
+// slog.TraceField key as for logger.Trace), remote address and user agent. Server errors are
+// logged at the error level, client errors at the warn level and all other requests at the info
+// level. The wrapped handler finds a logger carrying the request ID in the request context, see
+// slog.FromContext.
+type Handler struct {
+	handler http.Handler
+	logger  slf.StructuredLogger
+	header  string
+}
+
+// New constructs a middleware logging requests handled by the given handler through the logger,
+// which is usually a context logger of the log factory.
+func New(logger slf.StructuredLogger, handler http.Handler) *Handler {
+	return &Handler{handler: handler, logger: logger, header: StandardRequestIDHeader}
+}
+
+// SetRequestIDHeader defines the header carrying the request ID (default: X-Request-ID). The ID
+// of an incoming request is reused unless longer than 128 bytes, otherwise a random one is
+// generated; it is set on the response.
+func (h *Handler) SetRequestIDHeader(header string) {
+	h.header = header
+}
+
+// ServeHTTP implements the http.Handler interface. If the wrapped handler panics, the request is
+// logged with the status 500 before the panic is propagated, unless it panics with
+// http.ErrAbortHandler to abort the response, which is propagated without logging as by net/http.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	clock := slog.ClockOf(h.logger)
+	start := clock.Now()
+	id := r.Header.Get(h.header)
+	if id == "" || len(id) > maxRequestID {
+		id = newid()
+	}
+	w.Header().Set(h.header, id)
+
+	ctx := slog.NewFieldsContext(r.Context(), slf.Fields{RequestIDField: id})
+	if sc, err := slog.ParseTraceparent(r.Header.Get(traceparentHeader)); err == nil {
+		ctx = slog.NewSpanContext(ctx, sc)
+	}
+	var logger slf.StructuredLogger
+	if l, ok := h.logger.(slog.StructuredLogger); ok {
+		logger = l.WithCtx(ctx)
+	} else {
+		logger = h.logger.WithField(RequestIDField, id)
+	}
+	ctx = slog.NewContext(ctx, logger)
+
+	rw := &responsewriter{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		status := rw.status
+		p := recover()
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		if p != nil {
+			status = http.StatusInternalServerError
+		}
+		logger.WithFields(slf.Fields{
+			MethodField:     r.Method,
+			PathField:       r.URL.Path,
+			StatusField:     status,
+			BytesField:      rw.bytes,
+			slog.TraceField: clock.Now().Sub(start),
+			RemoteAddrField: r.RemoteAddr,
+			UserAgentField:  r.UserAgent(),
+		}).WithCaller(slf.CallerNone).Log(level(status), accessMessage)
+		if p != nil {
+			panic(p)
+		}
+	}()
+	h.handler.ServeHTTP(rw, r.WithContext(ctx))
+}
+
+func level(status int) slf.Level {
+	switch {
+	case status >= 500:
+		return slf.LevelError
+	case status >= 400:
+		return slf.LevelWarn
+	}
+	return slf.LevelInfo
+}
+
+func newid() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// responsewriter records the status and the number of bytes written.
+type responsewriter struct {
+	http.ResponseWriter
+	status  int
+	bytes   int
+	written bool
+}
+
+func (w *responsewriter) WriteHeader(status int) {
+	// informational statuses precede the final one, as in net/http
+	if !w.written && (status >= 200 || status == http.StatusSwitchingProtocols) {
+		w.status = status
+		w.written = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responsewriter) Write(b []byte) (int, error) {
+	w.written = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer does.
+func (w *responsewriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.written = true
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying writer does.
+func (w *responsewriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("httplog: the underlying writer does not implement http.Hijacker")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.written = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *responsewriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
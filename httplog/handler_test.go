@@ -0,0 +1,211 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package httplog_test
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/httplog"
+	"github.com/ventu-io/slog/slogtest"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testhandler struct {
+	entries []slog.Entry
+}
+
+func (th *testhandler) Handle(entry slog.Entry) error {
+	th.entries = append(th.entries, entry)
+	return nil
+}
+
+func setup() (*testhandler, slog.LogFactory) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	return th, lf
+}
+
+func TestHandler_accessLog_success(t *testing.T) {
+	th, lf := setup()
+	h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.FromContext(r.Context()).Info("handling")
+		fmt.Fprint(w, "hello")
+	}))
+
+	r := httptest.NewRequest("GET", "/hello?name=john", nil)
+	r.Header.Set("User-Agent", "test")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if len(th.entries) != 2 {
+		t.Fatalf("expected 2 entries, %v", th.entries)
+	}
+	id := w.Header().Get(httplog.StandardRequestIDHeader)
+	if len(id) != 32 {
+		t.Errorf("expected generated request ID, %q", id)
+	}
+	if fields := th.entries[0].Fields(); fields[httplog.RequestIDField] != id || fields[slog.ContextField] != "http" {
+		t.Errorf("expected request logger in context, %v", fields)
+	}
+	e := th.entries[1]
+	fields := e.Fields()
+	if e.Level() != slf.LevelInfo || e.Message() != "request" {
+		t.Errorf("unexpected access entry, %v %v", e.Level(), e.Message())
+	}
+	if fields[httplog.RequestIDField] != id || fields[httplog.MethodField] != "GET" || fields[httplog.PathField] != "/hello" ||
+		fields[httplog.StatusField] != 200 || fields[httplog.BytesField] != 5 || fields[httplog.UserAgentField] != "test" ||
+		fields[httplog.RemoteAddrField] != r.RemoteAddr {
+		t.Errorf("unexpected access fields, %v", fields)
+	}
+	if _, ok := fields[slog.TraceField].(time.Duration); !ok {
+		t.Errorf("expected duration, %v", fields)
+	}
+}
+
+func TestHandler_levelByStatus_success(t *testing.T) {
+	th, lf := setup()
+	for status, level := range map[int]slf.Level{
+		http.StatusNoContent:           slf.LevelInfo,
+		http.StatusFound:               slf.LevelInfo,
+		http.StatusNotFound:            slf.LevelWarn,
+		http.StatusInternalServerError: slf.LevelError,
+	} {
+		th.entries = nil
+		status := status
+		h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+		if len(th.entries) != 1 || th.entries[0].Level() != level || th.entries[0].Fields()[httplog.StatusField] != status {
+			t.Errorf("expected %v for %v, %v", level, status, th.entries)
+		}
+	}
+}
+
+func TestHandler_incomingHeaders_success(t *testing.T) {
+	th, lf := setup()
+	lf.AddContextExtractor(slog.TraceExtractor(nil))
+	h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.SetRequestIDHeader("X-Correlation-ID")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Correlation-ID", "abc")
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Header().Get("X-Correlation-ID") != "abc" {
+		t.Errorf("expected incoming request ID on response, %v", w.Header())
+	}
+	fields := th.entries[0].Fields()
+	if fields[httplog.RequestIDField] != "abc" || fields[slog.TraceIDField] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected incoming request ID and trace, %v", fields)
+	}
+}
+
+func TestHandler_durationByFactoryClock_success(t *testing.T) {
+	th, lf := setup()
+	clock := slogtest.NewFakeClock(time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC))
+	lf.SetClock(clock)
+	h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Add(250 * time.Millisecond)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if len(th.entries) != 1 || th.entries[0].Fields()[slog.TraceField] != 250*time.Millisecond {
+		t.Errorf("expected duration by the factory clock, %v", th.entries)
+	}
+}
+
+func TestHandler_onPanic_logsAndRepanics_success(t *testing.T) {
+	th, lf := setup()
+	h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("expected panic propagated, %v", p)
+		}
+		if len(th.entries) != 1 {
+			t.Fatalf("expected access entry, %v", th.entries)
+		}
+		if e := th.entries[0]; e.Level() != slf.LevelError || e.Fields()[httplog.StatusField] != http.StatusInternalServerError {
+			t.Errorf("unexpected access entry, %v %v", e.Level(), e.Fields())
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestHandler_panicAbortHandler_notLogged_success(t *testing.T) {
+	th, lf := setup()
+	h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("expected panic propagated, %v", p)
+		}
+		if len(th.entries) != 0 {
+			t.Errorf("expected no access entry, %v", th.entries)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestHandler_informationalStatus_ignored_success(t *testing.T) {
+	th, lf := setup()
+	h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if len(th.entries) != 1 || th.entries[0].Fields()[httplog.StatusField] != http.StatusNotFound {
+		t.Errorf("expected final status, %v", th.entries)
+	}
+}
+
+// hijackrecorder represents a recorder supporting http.Hijacker.
+type hijackrecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (r *hijackrecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, bufio.NewReadWriter(bufio.NewReader(r.conn), bufio.NewWriter(r.conn)), nil
+}
+
+func TestHandler_hijack_success(t *testing.T) {
+	th, lf := setup()
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	h := httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil || conn != server {
+			t.Errorf("expected hijacked connection, %v", err)
+		}
+	}))
+	h.ServeHTTP(&hijackrecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}, httptest.NewRequest("GET", "/", nil))
+	if len(th.entries) != 1 {
+		t.Errorf("expected access entry, %v", th.entries)
+	}
+
+	h = httplog.New(lf.WithContext("http"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			t.Error("expected an error")
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package json_test
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/json"
+	"github.com/ventu-io/slog/slogtest"
+	"io"
+	"testing"
+)
+
+func TestHandler_conformance_success(t *testing.T) {
+	slogtest.RunHandlerTests(t, func(w io.Writer) slog.EntryHandler {
+		return json.New(w)
+	}, parse)
+}
+
+type record struct {
+	Level   string
+	Message string
+	Error   string
+	Fields  map[string]interface{}
+}
+
+func parse(output []byte) ([]slogtest.Record, error) {
+	var res []slogtest.Record
+	dec := stdjson.NewDecoder(bytes.NewReader(output))
+	dec.UseNumber()
+	for dec.More() {
+		var r record
+		if err := dec.Decode(&r); err != nil {
+			return nil, err
+		}
+		level, err := slogtest.ParseLevel(r.Level)
+		if err != nil {
+			return nil, err
+		}
+		fields := make(map[string]string, len(r.Fields))
+		for key, value := range r.Fields {
+			switch v := value.(type) {
+			case string:
+				fields[key] = v
+			case stdjson.Number, bool:
+				fields[key] = fmt.Sprint(v)
+			default:
+				b, err := stdjson.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				fields[key] = string(b)
+			}
+		}
+		res = append(res, slogtest.Record{Level: level, Message: r.Message, Error: r.Error, Fields: fields})
+	}
+	return res, nil
+}
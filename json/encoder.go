@@ -0,0 +1,309 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package json
+
+import (
+	"encoding/json"
+	"github.com/ventu-io/slog"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hex = "0123456789abcdef"
+
+// encoder encodes the visited fields into JSON values, falling back to encoding/json for objects.
+type encoder struct {
+	fields []field
+	err    error
+}
+
+func (enc *encoder) add(key string, value []byte) {
+	enc.fields = append(enc.fields, field{key: key, value: value})
+}
+
+func (enc *encoder) VisitString(key string, value string) {
+	enc.add(key, appendString(nil, value))
+}
+
+func (enc *encoder) VisitInt64(key string, value int64) {
+	enc.add(key, strconv.AppendInt(nil, value, 10))
+}
+
+func (enc *encoder) VisitFloat64(key string, value float64) {
+	enc.add(key, appendFloat(nil, value))
+}
+
+func (enc *encoder) VisitBool(key string, value bool) {
+	enc.add(key, strconv.AppendBool(nil, value))
+}
+
+func (enc *encoder) VisitDuration(key string, value time.Duration) {
+	enc.add(key, appendDuration(nil, value))
+}
+
+func (enc *encoder) VisitTime(key string, value time.Time) {
+	enc.add(key, appendTime(nil, value))
+}
+
+func (enc *encoder) VisitError(key string, value error) {
+	enc.add(key, appendString(nil, value.Error()))
+}
+
+func (enc *encoder) VisitObject(key string, value interface{}) {
+	s, err := appendReflected(nil, value)
+	if err != nil {
+		if enc.err == nil {
+			enc.err = err
+		}
+		return
+	}
+	enc.add(key, s)
+}
+
+// objectencoder encodes an object marshaler into a JSON object, keeping the order of keys.
+type objectencoder struct {
+	s     []byte
+	empty bool
+}
+
+func (o *objectencoder) key(key string) {
+	if !o.empty {
+		o.s = append(o.s, ',')
+	}
+	o.empty = false
+	o.s = appendString(o.s, key)
+	o.s = append(o.s, ':')
+}
+
+func (o *objectencoder) AddString(key string, value string) {
+	o.key(key)
+	o.s = appendString(o.s, value)
+}
+
+func (o *objectencoder) AddInt64(key string, value int64) {
+	o.key(key)
+	o.s = strconv.AppendInt(o.s, value, 10)
+}
+
+func (o *objectencoder) AddFloat64(key string, value float64) {
+	o.key(key)
+	o.s = appendFloat(o.s, value)
+}
+
+func (o *objectencoder) AddBool(key string, value bool) {
+	o.key(key)
+	o.s = strconv.AppendBool(o.s, value)
+}
+
+func (o *objectencoder) AddDuration(key string, value time.Duration) {
+	o.key(key)
+	o.s = appendDuration(o.s, value)
+}
+
+func (o *objectencoder) AddTime(key string, value time.Time) {
+	o.key(key)
+	o.s = appendTime(o.s, value)
+}
+
+func (o *objectencoder) AddObject(key string, value slog.ObjectMarshaler) error {
+	o.key(key)
+	o.s = appendObject(o.s, value)
+	return nil
+}
+
+func (o *objectencoder) AddArray(key string, value slog.ArrayMarshaler) error {
+	o.key(key)
+	o.s = appendArray(o.s, value)
+	return nil
+}
+
+func (o *objectencoder) AddReflected(key string, value interface{}) (err error) {
+	o.key(key)
+	o.s, err = appendReflected(o.s, value)
+	return err
+}
+
+// arrayencoder encodes an array marshaler into a JSON array.
+type arrayencoder struct {
+	s     []byte
+	empty bool
+}
+
+func (a *arrayencoder) next() {
+	if !a.empty {
+		a.s = append(a.s, ',')
+	}
+	a.empty = false
+}
+
+func (a *arrayencoder) AppendString(value string) {
+	a.next()
+	a.s = appendString(a.s, value)
+}
+
+func (a *arrayencoder) AppendInt64(value int64) {
+	a.next()
+	a.s = strconv.AppendInt(a.s, value, 10)
+}
+
+func (a *arrayencoder) AppendFloat64(value float64) {
+	a.next()
+	a.s = appendFloat(a.s, value)
+}
+
+func (a *arrayencoder) AppendBool(value bool) {
+	a.next()
+	a.s = strconv.AppendBool(a.s, value)
+}
+
+func (a *arrayencoder) AppendDuration(value time.Duration) {
+	a.next()
+	a.s = appendDuration(a.s, value)
+}
+
+func (a *arrayencoder) AppendTime(value time.Time) {
+	a.next()
+	a.s = appendTime(a.s, value)
+}
+
+func (a *arrayencoder) AppendObject(value slog.ObjectMarshaler) error {
+	a.next()
+	a.s = appendObject(a.s, value)
+	return nil
+}
+
+func (a *arrayencoder) AppendArray(value slog.ArrayMarshaler) error {
+	a.next()
+	a.s = appendArray(a.s, value)
+	return nil
+}
+
+func (a *arrayencoder) AppendReflected(value interface{}) (err error) {
+	a.next()
+	a.s, err = appendReflected(a.s, value)
+	return err
+}
+
+// appendObject appends the object, or the error of the marshaler in its place as a string
+// formatted as by the basic handler, e.g. "!(ERROR=failed)".
+func appendObject(s []byte, value slog.ObjectMarshaler) []byte {
+	o := &objectencoder{s: append(s, '{'), empty: true}
+	if err := value.MarshalLogObject(o); err != nil {
+		return appendMarshalerError(s, err)
+	}
+	return append(o.s, '}')
+}
+
+// appendArray appends the array, or the error of the marshaler in its place, see appendObject.
+func appendArray(s []byte, value slog.ArrayMarshaler) []byte {
+	a := &arrayencoder{s: append(s, '['), empty: true}
+	if err := value.MarshalLogArray(a); err != nil {
+		return appendMarshalerError(s, err)
+	}
+	return append(a.s, ']')
+}
+
+func appendMarshalerError(s []byte, err error) []byte {
+	return appendString(s, "!(ERROR="+err.Error()+")")
+}
+
+// appendReflected appends object and array marshalers via their encoders, other values
+// marshalled by encoding/json.
+func appendReflected(s []byte, value interface{}) ([]byte, error) {
+	switch m := value.(type) {
+	case slog.ObjectMarshaler:
+		return appendObject(s, m), nil
+	case slog.ArrayMarshaler:
+		return appendArray(s, m), nil
+	}
+	b, err := json.Marshal(value)
+	return append(s, b...), err
+}
+
+// appendDuration appends durations as integer nanoseconds like encoding/json.
+func appendDuration(s []byte, d time.Duration) []byte {
+	return strconv.AppendInt(s, int64(d), 10)
+}
+
+func appendTime(s []byte, tm time.Time) []byte {
+	s = append(s, '"')
+	s = tm.AppendFormat(s, time.RFC3339Nano)
+	return append(s, '"')
+}
+
+// appendString appends the JSON string literal escaped the same way as by encoding/json.
+func appendString(s []byte, str string) []byte {
+	s = append(s, '"')
+	start := 0
+	for i := 0; i < len(str); {
+		if b := str[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			s = append(s, str[start:i]...)
+			switch b {
+			case '"', '\\':
+				s = append(s, '\\', b)
+			case '\n':
+				s = append(s, '\\', 'n')
+			case '\r':
+				s = append(s, '\\', 'r')
+			case '\t':
+				s = append(s, '\\', 't')
+			case '\b':
+				s = append(s, '\\', 'b')
+			case '\f':
+				s = append(s, '\\', 'f')
+			default:
+				s = append(s, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(str[i:])
+		if r == utf8.RuneError && size == 1 {
+			s = append(s, str[start:i]...)
+			s = append(s, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			s = append(s, str[start:i]...)
+			s = append(s, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	s = append(s, str[start:]...)
+	return append(s, '"')
+}
+
+// appendFloat appends the float formatted the same way as by encoding/json, except that NaN and
+// infinities, unsupported by JSON, are output as strings rather than failing.
+func appendFloat(s []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendString(s, strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	s = strconv.AppendFloat(s, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+			s[n-2] = s[n-1]
+			s = s[:n-1]
+		}
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package json_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/json"
+	"github.com/ventu-io/slog/slogtest"
+	"testing"
+	"time"
+)
+
+func TestHandler_golden_success(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := json.New(buf)
+	h.SetAddingEOL(true)
+	clock := slogtest.NewFakeClock(time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC))
+	lf := slog.New()
+	lf.SetClock(clock)
+	lf.SetConcurrent(false)
+	lf.SetLevel(slf.LevelDebug)
+	lf.SetCallerInfo(slf.CallerLong, "caller")
+	lf.Use(slogtest.StableCallers())
+	lf.AddEntryHandler(h)
+
+	logger := lf.WithContext("golden")
+	logger.WithFields(slf.Fields{"b": 2, "a": "one"}).Debug("debug")
+	typed := logger.(slog.StructuredLogger).WithTypedFields(slog.Int64("n", 5), slog.Duration("d", time.Second))
+	tracer := typed.Info("started")
+	clock.Add(250 * time.Millisecond)
+	err := errors.New("failed")
+	tracer.Trace(&err)
+	logger.WithError(err).Warn("warning")
+	lf.WithContext("caller").Error("with caller")
+
+	slogtest.Golden(t, "json", buf.Bytes())
+}
@@ -7,9 +7,9 @@ package json
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/ventu-io/slf"
 	"github.com/ventu-io/slog"
 	"io"
+	"sort"
 )
 
 // TODO add handing log in batches (on size or time interval)
@@ -46,34 +46,49 @@ func (h *Handler) SetAddingEOL(eol bool) {
 	h.addEOL = eol
 }
 
-type jsonentry struct {
-	Timestamp string                  `json:"timestamp"`
-	Level     slf.Level               `json:"level"`
-	Message   string                  `json:"message"`
-	Error     *string                 `json:"error,omitempty"`
-	Fields    *map[string]interface{} `json:"fields"`
-}
-
-// Handle processes the log entry formatting JSON into the given Writer.
+// Handle processes the log entry formatting JSON into the given Writer. The entry is output as
+// an object with the timestamp, level, message, error (if any) and fields keys, fields sorted
+// by key. Typed fields are encoded without reflection. The trace_id, span_id and trace_flags
+// fields are output at the top level rather than under fields.
 func (h *Handler) Handle(e slog.Entry) (err error) {
-	je := &jsonentry{
-		Timestamp: e.Time().Format(h.timeFormatStr),
-		Level:     e.Level(),
-		Message:   e.Message(),
+	s := []byte(`{"timestamp":`)
+	s = appendString(s, e.Time().Format(h.timeFormatStr))
+	level, err := json.Marshal(e.Level())
+	if err != nil {
+		return err
 	}
+	s = append(s, `,"level":`...)
+	s = append(s, level...)
+	s = append(s, `,"message":`...)
+	s = appendString(s, e.Message())
 	if e.Error() != nil {
-		errs := e.Error().Error()
-		je.Error = &errs
+		s = append(s, `,"error":`...)
+		s = appendString(s, e.Error().Error())
 	}
-	efields := e.Fields()
-	if len(efields) > 0 {
-		je.Fields = &efields
+	enc := &encoder{}
+	slog.VisitFields(e, enc)
+	if enc.err != nil {
+		return enc.err
 	}
-
-	s, err := json.Marshal(je)
-	if err != nil {
-		return err
+	sort.Sort(sortablefields(enc.fields))
+	s = appendCorrelation(s, enc)
+	s = append(s, `,"fields":`...)
+	if len(enc.fields) > 0 {
+		s = append(s, '{')
+		for i, f := range enc.fields {
+			if i > 0 {
+				s = append(s, ',')
+			}
+			s = appendString(s, f.key)
+			s = append(s, ':')
+			s = append(s, f.value...)
+		}
+		s = append(s, '}')
+	} else {
+		s = append(s, "null"...)
 	}
+	s = append(s, '}')
+
 	if h.addEOL {
 		s = append(s, eol)
 	}
@@ -86,3 +101,41 @@ func (h *Handler) Handle(e slog.Entry) (err error) {
 	}
 	return nil
 }
+
+// appendCorrelation moves the trace correlation fields, if any, from the fields to the top level
+// of the entry as in the OpenTelemetry log data model.
+func appendCorrelation(s []byte, enc *encoder) []byte {
+	fields := enc.fields[:0]
+	for _, f := range enc.fields {
+		switch f.key {
+		case slog.TraceIDField, slog.SpanIDField, slog.TraceFlagsField:
+			s = append(s, ',')
+			s = appendString(s, f.key)
+			s = append(s, ':')
+			s = append(s, f.value...)
+		default:
+			fields = append(fields, f)
+		}
+	}
+	enc.fields = fields
+	return s
+}
+
+type field struct {
+	key   string
+	value []byte
+}
+
+type sortablefields []field
+
+func (sf sortablefields) Len() int {
+	return len(sf)
+}
+
+func (sf sortablefields) Swap(i, j int) {
+	sf[i], sf[j] = sf[j], sf[i]
+}
+
+func (sf sortablefields) Less(i, j int) bool {
+	return sf[i].key < sf[j].key
+}
@@ -249,3 +249,122 @@ func TestHandler_EOL_success(t *testing.T) {
 		t.Errorf("no EOL, %v", sw.res)
 	}
 }
+
+func TestJSON_typedFields_success(t *testing.T) {
+	lf := slog.New()
+	i := &interceptor{entry: make(chan slog.Entry, 1)}
+	lf.AddEntryHandler(i)
+	lf.SetConcurrent(false)
+
+	tm := time.Date(2016, 3, 26, 17, 41, 14, 5, time.UTC)
+	lf.WithContext("json").(slog.StructuredLogger).WithTypedFields(
+		slog.String("s", "quote\" <tag>\n"),
+		slog.Int64("i", -3),
+		slog.Float64("f", 1e-7),
+		slog.Bool("b", true),
+		slog.Duration("d", time.Millisecond),
+		slog.Time("t", tm),
+		slog.Err(fmt.Errorf("boom")),
+		slog.Object("o", map[string]int{"A": 25}),
+	).Info("typed")
+
+	sw := &stringwriter{}
+	h := json.New(sw)
+	if err := h.Handle(<-i.entry); err != nil {
+		t.Error(err)
+	}
+	expected := `"fields":{"b":true,"context":"json","d":1000000,"error":"boom","f":1e-7,"i":-3,` +
+		`"o":{"A":25},"s":"quote\" \u003ctag\u003e\n","t":"2016-03-26T17:41:14.000000005Z"}}`
+	if !strings.Contains(sw.res, expected) {
+		t.Errorf("unexpected json, %v", sw.res)
+	}
+}
+
+type account struct {
+	name  string
+	roles []string
+}
+
+func (a account) MarshalLogObject(enc slog.ObjectEncoder) error {
+	enc.AddString("name", a.name)
+	enc.AddInt64("id", 7)
+	return enc.AddArray("roles", slog.ArrayMarshalerFunc(func(arr slog.ArrayEncoder) error {
+		for _, role := range a.roles {
+			arr.AppendString(role)
+		}
+		return arr.AppendObject(slog.ObjectMarshalerFunc(func(enc slog.ObjectEncoder) error {
+			enc.AddBool("empty", true)
+			return nil
+		}))
+	}))
+}
+
+func TestJSON_objectMarshaler_success(t *testing.T) {
+	lf := slog.New()
+	i := &interceptor{entry: make(chan slog.Entry, 2)}
+	lf.AddEntryHandler(i)
+	lf.SetConcurrent(false)
+
+	acc := account{name: "jdoe", roles: []string{"dev", "ops"}}
+	lf.WithContext("json").WithField("user", acc).Info("untyped")
+	lf.WithContext("json").(slog.StructuredLogger).WithTypedFields(slog.Object("user", acc)).Info("typed")
+
+	sw := &stringwriter{}
+	h := json.New(sw)
+	for n := 0; n < 2; n++ {
+		if err := h.Handle(<-i.entry); err != nil {
+			t.Error(err)
+		}
+	}
+	expected := `"fields":{"context":"json","user":{"name":"jdoe","id":7,"roles":["dev","ops",{"empty":true}]}}}`
+	if strings.Count(sw.res, expected) != 2 {
+		t.Errorf("unexpected json, %v", sw.res)
+	}
+}
+
+func TestJSON_onObjectMarshalerError_success(t *testing.T) {
+	lf := slog.New()
+	i := &interceptor{entry: make(chan slog.Entry, 1)}
+	lf.AddEntryHandler(i)
+	lf.SetConcurrent(false)
+
+	failing := slog.ObjectMarshalerFunc(func(enc slog.ObjectEncoder) error {
+		enc.AddString("partial", "value")
+		return fmt.Errorf("marshaler error")
+	})
+	lf.WithContext("json").WithField("A", failing).(slog.StructuredLogger).WithTypedFields(slog.Object("B", slog.ArrayMarshalerFunc(func(enc slog.ArrayEncoder) error {
+		enc.AppendString("first")
+		return enc.AppendObject(failing)
+	}))).Info("info")
+
+	sw := &stringwriter{}
+	h := json.New(sw)
+	if err := h.Handle(<-i.entry); err != nil {
+		t.Fatal(err)
+	}
+	expected := `"fields":{"A":"!(ERROR=marshaler error)","B":["first","!(ERROR=marshaler error)"],"context":"json"}}`
+	if !strings.Contains(sw.res, expected) {
+		t.Errorf("expected errors in place of the values, %v", sw.res)
+	}
+}
+
+func TestJSON_traceCorrelation_success(t *testing.T) {
+	lf := slog.New()
+	i := &interceptor{entry: make(chan slog.Entry, 1)}
+	lf.AddEntryHandler(i)
+	lf.SetConcurrent(false)
+
+	sc, _ := slog.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	lf.WithContext("json").WithFields(sc.Fields()).Info("traced")
+
+	sw := &stringwriter{}
+	h := json.New(sw)
+	if err := h.Handle(<-i.entry); err != nil {
+		t.Error(err)
+	}
+	expected := `"message":"traced","span_id":"00f067aa0ba902b7","trace_flags":"01",` +
+		`"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","fields":{"context":"json"}}`
+	if !strings.Contains(sw.res, expected) {
+		t.Errorf("unexpected json, %v", sw.res)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/ventu-io/slf"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -20,33 +22,49 @@ const (
 type LogFactory interface {
 	slf.LogFactory
 	SetLevel(level slf.Level, contexts ...string)
+	Level(context string) slf.Level
+	SetFields(fields slf.Fields, contexts ...string)
+	SetCallerInfo(callerInfo slf.CallerInfo, contexts ...string)
 	AddEntryHandler(handler EntryHandler)
 	SetEntryHandlers(handlers ...EntryHandler)
 	Contexts() map[string]slf.StructuredLogger
 	SetConcurrent(conc bool)
+	Flush() error
+	Use(middleware ...Middleware)
+	AddContextExtractor(extractor ContextExtractor)
+	SetClock(clock Clock)
 }
 
 // New constructs a new logger conforming with SLF.
 func New() LogFactory {
 	res := &logFactory{
 		root: rootLogger{
-			minlevel: slf.LevelInfo,
-			caller:   slf.CallerNone,
+			minlevel: int32(slf.LevelInfo),
+			caller:   int32(slf.CallerNone),
 		},
 		contexts:   make(map[string]*logger),
-		concurrent: true,
+		concurrent: 1,
 	}
 	res.root.factory = res
+	res.pipeline.Store(&pipeline{})
+	res.clock.Store(clockholder{SystemClock})
+	res.pending.current.Store(newgeneration(nil))
 	return res
 }
 
 // factory implements the slog.Logger interface.
 type logFactory struct {
 	sync.RWMutex
-	root       rootLogger
-	contexts   map[string]*logger
-	handlers   []EntryHandler
-	concurrent bool
+	root     rootLogger
+	contexts map[string]*logger
+	// *pipeline, replaced as a whole on change
+	pipeline   atomic.Value
+	extractors []ContextExtractor
+	// clockholder, atomic
+	clock atomic.Value
+	// 1 if concurrent, atomic
+	concurrent int32
+	pending    pending
 }
 
 // WithContext delivers a logger for the given context (reusing loggers for the same context).
@@ -59,14 +77,22 @@ func (lf *logFactory) WithContext(context string) slf.StructuredLogger {
 	}
 	fields := make(map[string]interface{})
 	fields[ContextField] = context
+	lf.Lock()
+	defer lf.Unlock()
+	// created concurrently in the meantime
+	if ctx, ok = lf.contexts[context]; ok {
+		return ctx
+	}
 	ctx = &logger{
-		rootLogger: &rootLogger{minlevel: lf.root.minlevel, factory: lf.root.factory, caller: lf.root.caller},
-		fields:     fields,
-		caller:     lf.root.caller,
+		rootLogger: &rootLogger{
+			minlevel: int32(lf.root.level()),
+			caller:   int32(lf.root.callerinfo()),
+			factory:  lf.root.factory,
+		},
+		fields: fields,
+		caller: callerRoot,
 	}
-	lf.Lock()
 	lf.contexts[context] = ctx
-	lf.Unlock()
 	return ctx
 }
 
@@ -75,10 +101,10 @@ func (lf *logFactory) WithContext(context string) slf.StructuredLogger {
 func (lf *logFactory) SetLevel(level slf.Level, contexts ...string) {
 	// set on all current and root
 	if len(contexts) == 0 {
-		lf.root.minlevel = level
+		lf.root.setlevel(level)
 		lf.Lock()
 		for _, logger := range lf.contexts {
-			logger.rootLogger.minlevel = level
+			logger.rootLogger.setlevel(level)
 		}
 		lf.Unlock()
 		return
@@ -87,9 +113,43 @@ func (lf *logFactory) SetLevel(level slf.Level, contexts ...string) {
 	for _, context := range contexts {
 		if strings.ToLower(context) != rootLevelKey {
 			logger, _ := lf.WithContext(context).(*logger) // locks internally
-			logger.rootLogger.minlevel = level
+			logger.rootLogger.setlevel(level)
 		} else {
-			lf.root.minlevel = level
+			lf.root.setlevel(level)
+		}
+	}
+}
+
+// Level returns the logging slf.Level of the given context, or of the root logger if no logger has
+// been delivered for the context yet, without delivering one.
+func (lf *logFactory) Level(context string) slf.Level {
+	lf.RLock()
+	ctx, ok := lf.contexts[context]
+	lf.RUnlock()
+	if !ok {
+		return lf.root.level()
+	}
+	return ctx.rootLogger.level()
+}
+
+// SetFields sets the fields added to every log entry: to all loggers if no context given or for
+// the "root" context, otherwise to the loggers of given contexts overriding the fields set for all.
+// Fields set on loggers take precedence over both. The given fields replace those set previously.
+func (lf *logFactory) SetFields(fields slf.Fields, contexts ...string) {
+	defaults := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		defaults[key] = value
+	}
+	if len(contexts) == 0 {
+		lf.root.fields.Store(defaults)
+		return
+	}
+	for _, context := range contexts {
+		if strings.ToLower(context) != rootLevelKey {
+			logger, _ := lf.WithContext(context).(*logger) // locks internally
+			logger.rootLogger.fields.Store(defaults)
+		} else {
+			lf.root.fields.Store(defaults)
 		}
 	}
 }
@@ -99,10 +159,10 @@ func (lf *logFactory) SetLevel(level slf.Level, contexts ...string) {
 func (lf *logFactory) SetCallerInfo(callerInfo slf.CallerInfo, contexts ...string) {
 	// set on all current and root
 	if len(contexts) == 0 {
-		lf.root.caller = callerInfo
+		lf.root.setcallerinfo(callerInfo)
 		lf.Lock()
 		for _, logger := range lf.contexts {
-			logger.rootLogger.caller = callerInfo
+			logger.rootLogger.setcallerinfo(callerInfo)
 		}
 		lf.Unlock()
 		return
@@ -111,9 +171,9 @@ func (lf *logFactory) SetCallerInfo(callerInfo slf.CallerInfo, contexts ...strin
 	for _, context := range contexts {
 		if strings.ToLower(context) != rootLevelKey {
 			logger, _ := lf.WithContext(context).(*logger) // locks internally
-			logger.rootLogger.caller = callerInfo
+			logger.rootLogger.setcallerinfo(callerInfo)
 		} else {
-			lf.root.caller = callerInfo
+			lf.root.setcallerinfo(callerInfo)
 		}
 	}
 }
@@ -121,18 +181,79 @@ func (lf *logFactory) SetCallerInfo(callerInfo slf.CallerInfo, contexts ...strin
 // AddEntryHandler adds a handler for log entries that are logged at or above the set
 // log slf.Level.
 func (lf *logFactory) AddEntryHandler(handler EntryHandler) {
-	lf.Lock()
-	lf.handlers = append(lf.handlers, handler)
-	lf.Unlock()
+	lf.update(func(p *pipeline) {
+		p.handlers = append(p.handlers, handler)
+	})
 }
 
 // SetEntryHandlers overwrites existing entry handlers with a new set.
 func (lf *logFactory) SetEntryHandlers(handlers ...EntryHandler) {
+	lf.update(func(p *pipeline) {
+		p.handlers = append([]EntryHandler{}, handlers...)
+	})
+}
+
+// Use appends middlewares transforming or dropping log entries before they are passed to the
+// entry handlers. Middlewares are executed in the order they were added.
+func (lf *logFactory) Use(middleware ...Middleware) {
+	lf.update(func(p *pipeline) {
+		p.middlewares = append(p.middlewares, middleware...)
+	})
+}
+
+// pipeline represents the middlewares and entry handlers, which are never modified in place:
+// loggers use them without locking.
+type pipeline struct {
+	middlewares []Middleware
+	handlers    []EntryHandler
+}
+
+func (lf *logFactory) load() *pipeline {
+	return lf.pipeline.Load().(*pipeline)
+}
+
+// update replaces the pipeline with a modified copy, appending to the copied slices never
+// modifies the previous ones.
+func (lf *logFactory) update(modify func(p *pipeline)) {
 	lf.Lock()
-	lf.handlers = append([]EntryHandler{}, handlers...)
+	p := *lf.load()
+	p.middlewares = p.middlewares[:len(p.middlewares):len(p.middlewares)]
+	p.handlers = p.handlers[:len(p.handlers):len(p.handlers)]
+	modify(&p)
+	lf.pipeline.Store(&p)
 	lf.Unlock()
 }
 
+// AddContextExtractor adds an extractor of fields from a context.Context applied by
+// StructuredLogger.WithCtx in addition to the fields carried by the context itself.
+func (lf *logFactory) AddContextExtractor(extractor ContextExtractor) {
+	lf.Lock()
+	// never append in place: loggers iterate the previous slice without locking
+	lf.extractors = append(lf.extractors[:len(lf.extractors):len(lf.extractors)], extractor)
+	lf.Unlock()
+}
+
+// SetClock sets the clock supplying entry time stamps and trace durations, the system clock if nil.
+func (lf *logFactory) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	lf.clock.Store(clockholder{clock})
+}
+
+func (lf *logFactory) now() time.Time {
+	return lf.clock.Load().(clockholder).Now()
+}
+
+// monotonic returns the nanoseconds since clockbase, on the monotonic clock of the system unless
+// the clock is set otherwise, never 0.
+func (lf *logFactory) monotonic() int64 {
+	if res := int64(lf.now().Sub(clockbase)); res != 0 {
+		return res
+	}
+	return 1
+}
+
 // Contexts returns all defined root logging contexts.
 func (lf *logFactory) Contexts() map[string]slf.StructuredLogger {
 	res := make(map[string]slf.StructuredLogger)
@@ -148,5 +269,95 @@ func (lf *logFactory) Contexts() map[string]slf.StructuredLogger {
 // output sequence of entries is not guaranteed to be the same as log entries input sequence,
 // although the timestamp will correspond the time of logging, not handling.
 func (lf *logFactory) SetConcurrent(conc bool) {
-	lf.concurrent = conc
+	var concurrent int32
+	if conc {
+		concurrent = 1
+	}
+	atomic.StoreInt32(&lf.concurrent, concurrent)
+}
+
+func (lf *logFactory) isconcurrent() bool {
+	return atomic.LoadInt32(&lf.concurrent) == 1
+}
+
+// Flush waits for the handling of entries logged concurrently before the call to complete and
+// flushes all entry handlers implementing Flusher, returning the first error encountered.
+func (lf *logFactory) Flush() error {
+	lf.pending.wait()
+	var res error
+	for _, handler := range lf.load().handlers {
+		if f, ok := handler.(Flusher); ok {
+			if err := f.Flush(); err != nil && res == nil {
+				res = err
+			}
+		}
+	}
+	return res
+}
+
+// pending tracks the entries being handled concurrently in generations, so that waiting covers
+// the entries added before the call only and completes under continuous logging. Adding entries
+// and completing them is lock-free.
+type pending struct {
+	// serialises waiters replacing the generation
+	sync.Mutex
+	// *generation, atomic
+	current atomic.Value
+}
+
+// generation counts its entries being handled, plus open while entries can be added to it.
+type generation struct {
+	count int64
+	once  sync.Once
+	// closed when the count drops to zero after the generation was closed
+	idle chan bool
+	// closed when this and all previous generations are done
+	finished chan bool
+	prev     *generation
+}
+
+const open = 1 << 40
+
+func newgeneration(prev *generation) *generation {
+	return &generation{count: open, idle: make(chan bool), finished: make(chan bool), prev: prev}
+}
+
+// add counts an entry in the current generation and returns the latter to call done on.
+func (p *pending) add() *generation {
+	for {
+		g := p.current.Load().(*generation)
+		if atomic.AddInt64(&g.count, 1) > open {
+			return g
+		}
+		// closed by a concurrent wait in the meantime, add to the next generation
+		g.done()
+	}
+}
+
+// done completes an entry of the generation.
+func (g *generation) done() {
+	g.release(1)
+}
+
+func (g *generation) release(n int64) {
+	if atomic.AddInt64(&g.count, -n) == 0 {
+		g.once.Do(func() {
+			close(g.idle)
+		})
+	}
+}
+
+// wait blocks until all entries added before the call are done.
+func (p *pending) wait() {
+	p.Lock()
+	g := p.current.Load().(*generation)
+	p.current.Store(newgeneration(g))
+	p.Unlock()
+	g.release(open)
+	<-g.idle
+	if g.prev != nil {
+		<-g.prev.finished
+		g.prev = nil
+	}
+	close(g.finished)
 }
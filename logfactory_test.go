@@ -4,8 +4,11 @@
 package slog_test
 
 import (
+	"errors"
 	"github.com/ventu-io/slf"
 	"github.com/ventu-io/slog"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -102,3 +105,179 @@ func TestLogger_addEntryHandlers_success(t *testing.T) {
 		t.Error("3 entries expected")
 	}
 }
+
+type flushhandler struct {
+	testhandler
+	flushed int
+}
+
+func (fh *flushhandler) Flush() error {
+	fh.flushed++
+	return fh.err
+}
+
+func TestLogger_flush_callsFlushers_success(t *testing.T) {
+	th := &testhandler{}
+	fh := &flushhandler{}
+	lf := slog.New()
+	lf.SetEntryHandlers(th, fh)
+
+	lf.WithContext("test").Info("test")
+	if err := lf.Flush(); err != nil {
+		t.Error(err)
+	}
+	if fh.flushed != 1 {
+		t.Error("expected flush")
+	}
+	if len(th.entries) != 1 || len(fh.entries) != 1 {
+		t.Error("expected concurrent handling completed")
+	}
+}
+
+type countinghandler struct {
+	handled int64
+}
+
+func (ch *countinghandler) Handle(entry slog.Entry) error {
+	atomic.AddInt64(&ch.handled, 1)
+	return nil
+}
+
+func TestLogger_flush_whileLoggingConcurrently_success(t *testing.T) {
+	const routines, count = 4, 200
+	ch := &countinghandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(ch)
+	logger := lf.WithContext("test")
+
+	var logged int64
+	var wg sync.WaitGroup
+	for g := 0; g < routines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				logger.Info("test")
+				atomic.AddInt64(&logged, 1)
+			}
+		}()
+	}
+	done := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for flushing := true; flushing; {
+		select {
+		case <-done:
+			flushing = false
+		default:
+		}
+		before := atomic.LoadInt64(&logged)
+		if err := lf.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if handled := atomic.LoadInt64(&ch.handled); handled < before {
+			t.Fatalf("expected at least %v entries handled after flush, found %v", before, handled)
+		}
+	}
+	if handled := atomic.LoadInt64(&ch.handled); handled != routines*count {
+		t.Errorf("expected %v entries handled, found %v", routines*count, handled)
+	}
+}
+
+func TestLogger_flush_concurrentFlushes_success(t *testing.T) {
+	const routines, count = 4, 100
+	ch := &countinghandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(ch)
+	logger := lf.WithContext("test")
+
+	var wg sync.WaitGroup
+	for g := 0; g < routines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				logger.Info("test")
+				if err := lf.Flush(); err != nil {
+					t.Error(err)
+				}
+				if handled := atomic.LoadInt64(&ch.handled); handled < int64(i+1) {
+					t.Errorf("expected at least %v entries handled after flush, found %v", i+1, handled)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if handled := atomic.LoadInt64(&ch.handled); handled != routines*count {
+		t.Errorf("expected %v entries handled, found %v", routines*count, handled)
+	}
+}
+
+func TestLogger_flush_onFlusherError_error(t *testing.T) {
+	fh := &flushhandler{testhandler: testhandler{err: errors.New("boom")}}
+	lf := slog.New()
+	lf.AddEntryHandler(fh)
+	if err := lf.Flush(); err == nil || err.Error() != "boom" {
+		t.Errorf("expected error, %v", err)
+	}
+}
+
+func TestLogger_setFields_mergedWithOverrides_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	logger0 := lf.WithContext("test0").WithField("version", "logger")
+	lf.SetFields(slf.Fields{"service": "app", "version": "1.0", slog.ContextField: "ignored"})
+	lf.SetFields(slf.Fields{"service": "worker"}, "test1")
+	logger0.Info("info0")
+	lf.WithContext("test1").Info("info1")
+	lf.WithContext("test2").Info("info2")
+
+	if fields := th.entries[0].Fields(); fields["service"] != "app" || fields["version"] != "logger" || fields[slog.ContextField] != "test0" {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+	if fields := th.entries[1].Fields(); fields["service"] != "worker" || fields["version"] != "1.0" || fields[slog.ContextField] != "test1" {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+	if fields := th.entries[2].Fields(); fields["service"] != "app" || fields["version"] != "1.0" || len(fields) != 3 {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+}
+
+func TestLogger_setFields_replacesAtRuntime_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	logger := lf.WithContext("test")
+	fields := slf.Fields{"pid": 1}
+	lf.SetFields(fields, "root")
+	fields["pid"] = 2
+	logger.Info("info0")
+	lf.SetFields(slf.Fields{"host": "localhost"})
+	logger.Info("info1")
+
+	if th.entries[0].Fields()["pid"] != 1 {
+		t.Errorf("expected fields copied, %v", th.entries[0].Fields())
+	}
+	if _, ok := th.entries[1].Fields()["pid"]; ok || th.entries[1].Fields()["host"] != "localhost" {
+		t.Errorf("expected fields replaced, %v", th.entries[1].Fields())
+	}
+}
+
+func TestLogger_level_success(t *testing.T) {
+	lf := slog.New()
+	lf.SetLevel(slf.LevelWarn, "test1")
+	lf.SetLevel(slf.LevelError, "root")
+	if lf.Level("test1") != slf.LevelWarn || lf.Level("test2") != slf.LevelError {
+		t.Errorf("unexpected levels, %v, %v", lf.Level("test1"), lf.Level("test2"))
+	}
+	if _, ok := lf.Contexts()["test2"]; ok {
+		t.Error("expected no logger delivered for the context")
+	}
+}
@@ -4,12 +4,13 @@
 package slog
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/ventu-io/slf"
-	stdlog "log"
 	"path"
 	"runtime"
+	"sync/atomic"
 	"time"
 	"os"
 )
@@ -25,19 +26,50 @@ const (
 	ErrorField = "error"
 
 	traceMessage = "trace"
+
+	// caller info of loggers following the setting of the root logger of their context
+	callerRoot slf.CallerInfo = -1
 )
 
 var (
-	noop  = &slf.Noop{}
-	epoch = time.Time{}
+	noop = &slf.Noop{}
+	// reference for monotonic timestamps of loggers
+	clockbase = time.Now()
 )
 
+// StructuredLogger extends the SLF StructuredLogger interface with methods specific to the slog
+// implementation. All loggers delivered by the log factory implement it.
+type StructuredLogger interface {
+	slf.StructuredLogger
+
+	// WithTypedFields adds typed fields to the logger, see Field.
+	WithTypedFields(fields ...Field) StructuredLogger
+
+	// WithCtx adds the fields carried by the context and those extracted from it by the context
+	// extractors of the log factory to the logger.
+	WithCtx(ctx context.Context) StructuredLogger
+
+	// StartTrace starts a named span timing independently of other spans and of Trace, see Span.
+	StartTrace(name string) *Span
+
+	// Enabled reports whether entries of the level are logged. Logging a message at a disabled
+	// level does not allocate, but arguments of the formatting methods are boxed and loggers with
+	// fields are constructed by the caller before the call, so these are best guarded by this
+	// check on hot paths, or replaced by Events.
+	Enabled(level slf.Level) bool
+}
+
 // rootLogger represents a root logger for a context, all other loggers in the same context
 // (with different fields) contain this one to identify the log level and entry handlers.
 type rootLogger struct {
-	minlevel slf.Level
+	// atomic: unix nanoseconds of the latest entry time stamp, first for 64-bit alignment
+	laststamp int64
+	// slf.Level and slf.CallerInfo, atomic
+	minlevel int32
+	caller   int32
 	factory  *logFactory
-	caller   slf.CallerInfo
+	// default fields, map[string]interface{} replaced as a whole on change
+	fields atomic.Value
 }
 
 // logger represents a logger in the context. It is created from the rootlogger by copying its
@@ -47,17 +79,20 @@ type logger struct {
 	*rootLogger
 	// not synced because ro outside of construction in with*
 	fields map[string]interface{}
+	typed  []Field
 	caller slf.CallerInfo
 	err    error
-	// not synced
-	lasttouch time.Time
-	lastlevel slf.Level
+	// atomic: nanoseconds since clockbase by the factory clock of the last entry (0 if none)
+	// and its slf.Level
+	lasttouch int64
+	lastlevel int32
 }
 
 // WithField implements the Logger interface.
 func (log *logger) WithField(key string, value interface{}) slf.StructuredLogger {
 	res := log.copy()
 	res.fields[key] = value
+	res.untype(key)
 	return res
 }
 
@@ -66,6 +101,38 @@ func (log *logger) WithFields(fields slf.Fields) slf.StructuredLogger {
 	res := log.copy()
 	for k, v := range fields {
 		res.fields[k] = v
+		res.untype(k)
+	}
+	return res
+}
+
+// WithTypedFields implements the StructuredLogger interface.
+func (log *logger) WithTypedFields(fields ...Field) StructuredLogger {
+	res := log.copy()
+	for _, f := range fields {
+		delete(res.fields, f.Key)
+		res.untype(f.Key)
+		res.typed = append(res.typed, f)
+	}
+	return res
+}
+
+// WithCtx implements the StructuredLogger interface.
+func (log *logger) WithCtx(ctx context.Context) StructuredLogger {
+	res := log.copy()
+	for key, value := range FieldsFromContext(ctx) {
+		res.fields[key] = value
+		res.untype(key)
+	}
+	f := log.rootLogger.factory
+	f.RLock()
+	extractors := f.extractors
+	f.RUnlock()
+	for _, extract := range extractors {
+		for key, value := range extract(ctx) {
+			res.fields[key] = value
+			res.untype(key)
+		}
 	}
 	return res
 }
@@ -84,6 +151,11 @@ func (log *logger) WithError(err error) slf.Logger {
 	return res
 }
 
+// Enabled implements the StructuredLogger interface.
+func (log *logger) Enabled(level slf.Level) bool {
+	return level >= log.rootLogger.level()
+}
+
 // Log implements the Logger interface.
 func (log *logger) Log(level slf.Level, message string) slf.Tracer {
 	return log.log(level, message)
@@ -91,19 +163,18 @@ func (log *logger) Log(level slf.Level, message string) slf.Tracer {
 
 // Trace implements the Logger interface.
 func (log *logger) Trace(err *error) {
-	lasttouch := log.lasttouch
-	level := log.lastlevel
-	if lasttouch != epoch && level >= log.rootLogger.minlevel {
+	lasttouch := atomic.SwapInt64(&log.lasttouch, 0)
+	level := slf.Level(atomic.LoadInt32(&log.lastlevel))
+	if lasttouch != 0 && level >= log.rootLogger.level() {
 		var entry *entry
 		if err != nil {
-			entry = log.entry(level, traceMessage, 2, *err)
+			entry = log.entry(level, traceMessage, 2, *err, log.typed, time.Time{})
 		} else {
-			entry = log.entry(level, traceMessage, 2, nil)
+			entry = log.entry(level, traceMessage, 2, nil, log.typed, time.Time{})
 		}
-		entry.fields[TraceField] = time.Now().Sub(lasttouch)
+		entry.fields[TraceField] = time.Duration(log.rootLogger.factory.monotonic() - lasttouch)
 		log.handleall(entry)
 	}
-	log.lasttouch = epoch
 }
 
 // Debug implements the Logger interface.
@@ -172,14 +243,14 @@ func (log *logger) Fatalf(format string, args ...interface{}) {
 
 // Log implements the Logger interface.
 func (log *logger) log(level slf.Level, message string) slf.Tracer {
-	if level < log.rootLogger.minlevel {
+	if level < log.rootLogger.level() {
 		return noop
 	}
 	return log.checkedlog(level, message)
 }
 
 func (log *logger) logf(format string, level slf.Level, args ...interface{}) slf.Tracer {
-	if level < log.rootLogger.minlevel {
+	if level < log.rootLogger.level() {
 		return noop
 	}
 	message := fmt.Sprintf(format, args...)
@@ -187,9 +258,9 @@ func (log *logger) logf(format string, level slf.Level, args ...interface{}) slf
 }
 
 func (log *logger) checkedlog(level slf.Level, message string) slf.Tracer {
-	log.handleall(log.entry(level, message, 4, log.err))
-	log.lasttouch = time.Now()
-	log.lastlevel = level
+	log.handleall(log.entry(level, message, 4, log.err, log.typed, time.Time{}))
+	atomic.StoreInt64(&log.lasttouch, log.rootLogger.factory.monotonic())
+	atomic.StoreInt32(&log.lastlevel, int32(level))
 	return log
 }
 
@@ -202,47 +273,132 @@ func (log *logger) copy() *logger {
 	for key, value := range log.fields {
 		res.fields[key] = value
 	}
+	if len(log.typed) > 0 {
+		res.typed = append(make([]Field, 0, len(log.typed)+1), log.typed...)
+	}
 	return res
 }
 
-func (log *logger) entry(level slf.Level, message string, skip int, err error) *entry {
+// untype removes the typed field with the given key from a freshly copied logger.
+func (log *logger) untype(key string) {
+	for i, f := range log.typed {
+		if f.Key == key {
+			log.typed = append(log.typed[:i], log.typed[i+1:]...)
+			return
+		}
+	}
+}
+
+// entry constructs the entry at the given time, or stamped by the factory clock if zero.
+func (log *logger) entry(level slf.Level, message string, skip int, err error, typed []Field, tm time.Time) *entry {
 	fields := make(map[string]interface{})
+	for key, value := range log.rootLogger.factory.root.defaults() {
+		fields[key] = value
+	}
+	for key, value := range log.rootLogger.defaults() {
+		fields[key] = value
+	}
+	// without defaults, which may be overridden, valuers are resolved while copying
+	merged := len(fields) > 0
 	for key, value := range log.fields {
+		if !merged {
+			value = resolve(value)
+		}
 		fields[key] = value
 	}
-	if log.caller == slf.CallerLong || log.caller == slf.CallerShort {
+	for _, f := range typed {
+		delete(fields, f.Key)
+	}
+	if merged {
+		for key, value := range fields {
+			fields[key] = resolve(value)
+		}
+	}
+	caller := log.caller
+	if caller == callerRoot {
+		caller = log.rootLogger.callerinfo()
+	}
+	if caller == slf.CallerLong || caller == slf.CallerShort {
 		if _, file, line, ok := runtime.Caller(skip); ok {
-			if log.caller == slf.CallerShort {
+			if caller == slf.CallerShort {
 				file = path.Base(file)
 			}
 			fields[CallerField] = fmt.Sprintf("%s:%d", file, line)
 		}
 	}
-	return &entry{tm: time.Now(), level: level, message: message, err: err, fields: fields}
+	if tm.IsZero() {
+		tm = log.rootLogger.stamp(log.rootLogger.factory.now())
+	}
+	return &entry{tm: tm, level: level, message: message, err: err, fields: fields, typed: typed}
+}
+
+// stamp returns the time, or the latest time stamp of the context if later, so that time stamps
+// never decrease within a context, also when the wall clock is set back.
+func (root *rootLogger) stamp(now time.Time) time.Time {
+	ns := now.UnixNano()
+	for {
+		last := atomic.LoadInt64(&root.laststamp)
+		if ns < last {
+			return time.Unix(0, last).In(now.Location())
+		}
+		if ns == last || atomic.CompareAndSwapInt64(&root.laststamp, last, ns) {
+			return now
+		}
+	}
+}
+
+func (root *rootLogger) level() slf.Level {
+	return slf.Level(atomic.LoadInt32(&root.minlevel))
+}
+
+func (root *rootLogger) setlevel(level slf.Level) {
+	atomic.StoreInt32(&root.minlevel, int32(level))
+}
+
+func (root *rootLogger) callerinfo() slf.CallerInfo {
+	return slf.CallerInfo(atomic.LoadInt32(&root.caller))
+}
+
+func (root *rootLogger) setcallerinfo(caller slf.CallerInfo) {
+	atomic.StoreInt32(&root.caller, int32(caller))
 }
 
-func (log *logger) handleall(entry *entry) {
+func (root *rootLogger) defaults() map[string]interface{} {
+	fields, _ := root.fields.Load().(map[string]interface{})
+	return fields
+}
+
+func (log *logger) handleall(entry Entry) {
 	f := log.rootLogger.factory
-	f.RLock()
-	handlers := make([]EntryHandler, len(f.handlers))
-	copy(handlers, f.handlers)
-	f.RUnlock()
+	p := f.load()
+	for _, middleware := range p.middlewares {
+		var ok bool
+		if entry, ok = middleware(entry); !ok {
+			return
+		}
+	}
 
-	for _, handler := range handlers {
-		if log.factory.concurrent {
-			go log.handleone(handler, entry)
+	concurrent := f.isconcurrent()
+	for _, handler := range p.handlers {
+		if concurrent {
+			go log.handleasync(handler, entry, f.pending.add())
 		} else {
 			log.handleone(handler, entry)
 		}
 	}
-	if log.factory.concurrent {
+	if concurrent {
 		runtime.Gosched()
 	}
 }
 
-func (log *logger) handleone(h EntryHandler, e *entry) {
+func (log *logger) handleasync(h EntryHandler, e Entry, gen *generation) {
+	defer gen.done()
+	log.handleone(h, e)
+}
+
+func (log *logger) handleone(h EntryHandler, e Entry) {
 	if err := h.Handle(e); err != nil {
 		// fall back to standard logging to output entry handler error
-		stdlog.Printf("log handler error: %v\n", err.Error())
+		ReportHandlerError(err)
 	}
 }
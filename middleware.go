@@ -0,0 +1,109 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"github.com/ventu-io/slf"
+	"sync"
+	"time"
+)
+
+// Middleware transforms log entries after they are created and before they are passed to the
+// entry handlers. It returns the entry to pass on, which can be the given entry, a modified copy
+// of it (see Derive) or a new one, and false to drop the entry. Middlewares are executed in the
+// order of registration in the logging go-routine, also when handling is concurrent, and must not
+// write to the field map of the entry they receive.
+type Middleware func(Entry) (Entry, bool)
+
+// DerivedEntry represents a modifiable copy of a log entry constructed by Derive.
+type DerivedEntry struct {
+	entry
+	copied bool
+}
+
+// Derive constructs a modifiable copy of the log entry. The copy shares the field map with the
+// original until a field is set or deleted, so that modifying the time, level, message or error
+// does not copy the fields. Typed fields of entries produced by slog loggers are retained unless
+// set or deleted, so that handlers visit them without boxing.
+func Derive(e Entry) *DerivedEntry {
+	res := &DerivedEntry{entry: entry{tm: e.Time(), level: e.Level(), message: e.Message(), err: e.Error()}}
+	if te, ok := e.(interface {
+		parts() (map[string]interface{}, []Field)
+	}); ok {
+		res.fields, res.typed = te.parts()
+	} else {
+		res.fields = e.Fields()
+	}
+	return res
+}
+
+// SetTime sets the entry time stamp.
+func (d *DerivedEntry) SetTime(tm time.Time) *DerivedEntry {
+	d.tm = tm
+	return d
+}
+
+// SetLevel sets the entry log level.
+func (d *DerivedEntry) SetLevel(level slf.Level) *DerivedEntry {
+	d.level = level
+	return d
+}
+
+// SetMessage sets the entry message.
+func (d *DerivedEntry) SetMessage(message string) *DerivedEntry {
+	d.message = message
+	return d
+}
+
+// SetError sets the entry error.
+func (d *DerivedEntry) SetError(err error) *DerivedEntry {
+	d.err = err
+	return d
+}
+
+// SetField sets a field, copying the field map of the original entry on first modification.
+func (d *DerivedEntry) SetField(key string, value interface{}) *DerivedEntry {
+	d.copy()
+	d.untype(key)
+	d.fields[key] = value
+	return d
+}
+
+// DeleteField removes a field, copying the field map of the original entry on first modification.
+func (d *DerivedEntry) DeleteField(key string) *DerivedEntry {
+	if _, ok := d.fields[key]; ok {
+		d.copy()
+		delete(d.fields, key)
+	}
+	d.untype(key)
+	return d
+}
+
+// untype removes the typed field with the key into a new slice, the original one being shared,
+// and discards the field map merged with the typed fields to reflect the modification.
+func (d *DerivedEntry) untype(key string) {
+	if len(d.typed) == 0 {
+		return
+	}
+	for i, f := range d.typed {
+		if f.Key == key {
+			typed := make([]Field, 0, len(d.typed)-1)
+			d.typed = append(append(typed, d.typed[:i]...), d.typed[i+1:]...)
+			break
+		}
+	}
+	d.once, d.merged = sync.Once{}, nil
+}
+
+func (d *DerivedEntry) copy() {
+	if d.copied {
+		return
+	}
+	fields := make(map[string]interface{}, len(d.fields)+1)
+	for key, value := range d.fields {
+		fields[key] = value
+	}
+	d.fields = fields
+	d.copied = true
+}
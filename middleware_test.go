@@ -0,0 +1,151 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware_enrichAndRewrite_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	lf.Use(func(e slog.Entry) (slog.Entry, bool) {
+		return slog.Derive(e).SetField("host", "localhost").DeleteField("secret"), true
+	}, func(e slog.Entry) (slog.Entry, bool) {
+		return slog.Derive(e).SetMessage(strings.ToUpper(e.Message())), true
+	})
+
+	lf.WithContext("test").WithField("secret", 1).WithField("key", 2).Info("message")
+	if len(th.entries) != 1 {
+		t.Fatal("expected 1 entry")
+	}
+	e := th.entries[0]
+	if e.Message() != "MESSAGE" {
+		t.Errorf("unexpected message, %v", e.Message())
+	}
+	fields := e.Fields()
+	if len(fields) != 3 || fields["host"] != "localhost" || fields["key"] != 2 || fields[slog.ContextField] != "test" {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+}
+
+func TestMiddleware_executedInOrder_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	appending := func(s string) slog.Middleware {
+		return func(e slog.Entry) (slog.Entry, bool) {
+			return slog.Derive(e).SetMessage(e.Message() + s), true
+		}
+	}
+	lf.Use(appending("1"), appending("2"))
+	lf.Use(appending("3"))
+
+	lf.WithContext("test").Info("0")
+	if th.entries[0].Message() != "0123" {
+		t.Errorf("unexpected order, %v", th.entries[0].Message())
+	}
+}
+
+func TestMiddleware_drop_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	called := false
+	lf.Use(func(e slog.Entry) (slog.Entry, bool) {
+		return e, e.Level() >= slf.LevelWarn
+	}, func(e slog.Entry) (slog.Entry, bool) {
+		called = true
+		return e, true
+	})
+
+	lf.WithContext("test").Info("dropped")
+	if len(th.entries) != 0 || called {
+		t.Error("expected entry dropped")
+	}
+	lf.WithContext("test").Warn("passed")
+	if len(th.entries) != 1 || !called {
+		t.Error("expected entry passed")
+	}
+}
+
+func TestDerive_copiesFieldsOnWrite_success(t *testing.T) {
+	i := &interceptor{entry: make(chan slog.Entry, 1)}
+	lf := slog.New()
+	lf.AddEntryHandler(i)
+	lf.SetConcurrent(false)
+	lf.WithContext("test").WithField("key", 1).Info("message")
+	orig := <-i.entry
+
+	tm := time.Now().Add(time.Hour)
+	d := slog.Derive(orig).SetTime(tm).SetLevel(slf.LevelError).SetError(errors.New("err"))
+	if d.Time() != tm || d.Level() != slf.LevelError || d.Error().Error() != "err" || d.Message() != "message" {
+		t.Errorf("unexpected derived entry, %v", d)
+	}
+	d.SetField("key", 2).SetField("other", 3).DeleteField(slog.ContextField)
+	if len(d.Fields()) != 2 || d.Fields()["key"] != 2 || d.Fields()["other"] != 3 {
+		t.Errorf("unexpected derived fields, %v", d.Fields())
+	}
+	if len(orig.Fields()) != 2 || orig.Fields()["key"] != 1 || orig.Level() != slf.LevelInfo {
+		t.Errorf("original entry modified, %v", orig.Fields())
+	}
+}
+
+type interceptor struct {
+	entry chan slog.Entry
+}
+
+func (i *interceptor) Handle(e slog.Entry) error {
+	i.entry <- e
+	return nil
+}
+
+type kindvisitor struct {
+	kinds map[string]string
+}
+
+func (v *kindvisitor) VisitString(key string, value string)          { v.kinds[key] = "string" }
+func (v *kindvisitor) VisitInt64(key string, value int64)            { v.kinds[key] = "int64" }
+func (v *kindvisitor) VisitFloat64(key string, value float64)        { v.kinds[key] = "float64" }
+func (v *kindvisitor) VisitBool(key string, value bool)              { v.kinds[key] = "bool" }
+func (v *kindvisitor) VisitDuration(key string, value time.Duration) { v.kinds[key] = "duration" }
+func (v *kindvisitor) VisitTime(key string, value time.Time)         { v.kinds[key] = "time" }
+func (v *kindvisitor) VisitError(key string, value error)            { v.kinds[key] = "error" }
+func (v *kindvisitor) VisitObject(key string, value interface{})     { v.kinds[key] = "object" }
+
+func TestDerive_retainsTypedFields_success(t *testing.T) {
+	i := &interceptor{entry: make(chan slog.Entry, 1)}
+	lf := slog.New()
+	lf.AddEntryHandler(i)
+	lf.SetConcurrent(false)
+	logger := lf.WithContext("test").(slog.StructuredLogger)
+	logger.WithTypedFields(slog.Object("a", "typed"), slog.Object("b", "typed"), slog.Object("c", "typed")).Info("message")
+	orig := <-i.entry
+
+	d := slog.Derive(orig)
+	if d.Fields()["a"] != "typed" {
+		t.Errorf("unexpected fields, %v", d.Fields())
+	}
+	d.SetField("b", "set").DeleteField("c")
+	v := &kindvisitor{kinds: make(map[string]string)}
+	slog.VisitFields(d, v)
+	if len(v.kinds) != 3 || v.kinds["a"] != "object" || v.kinds["b"] != "string" || v.kinds[slog.ContextField] != "string" {
+		t.Errorf("expected typed field retained, %v", v.kinds)
+	}
+	if fields := d.Fields(); len(fields) != 3 || fields["b"] != "set" {
+		t.Errorf("unexpected fields after modification, %v", fields)
+	}
+	if fields := orig.Fields(); len(fields) != 4 || fields["b"] != "typed" || fields["c"] != "typed" {
+		t.Errorf("original entry modified, %v", fields)
+	}
+}
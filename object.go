@@ -0,0 +1,185 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"strconv"
+	"time"
+)
+
+// ObjectMarshaler is implemented by types describing themselves to log handlers as a set of keys
+// and values, which handlers output e.g. as nested JSON objects or as dotted keys in text. Values
+// implementing it can be logged as any other field value or with the Object typed field. The
+// built-in handlers output an error returned by a marshaler in place of the value.
+type ObjectMarshaler interface {
+	MarshalLogObject(ObjectEncoder) error
+}
+
+// ArrayMarshaler is implemented by types describing themselves to log handlers as a list of values.
+type ArrayMarshaler interface {
+	MarshalLogArray(ArrayEncoder) error
+}
+
+// ObjectMarshalerFunc is a function implementing ObjectMarshaler.
+type ObjectMarshalerFunc func(ObjectEncoder) error
+
+// MarshalLogObject implements the ObjectMarshaler interface.
+func (f ObjectMarshalerFunc) MarshalLogObject(enc ObjectEncoder) error {
+	return f(enc)
+}
+
+// ArrayMarshalerFunc is a function implementing ArrayMarshaler.
+type ArrayMarshalerFunc func(ArrayEncoder) error
+
+// MarshalLogArray implements the ArrayMarshaler interface.
+func (f ArrayMarshalerFunc) MarshalLogArray(enc ArrayEncoder) error {
+	return f(enc)
+}
+
+// ObjectEncoder receives the keys and values of an ObjectMarshaler.
+type ObjectEncoder interface {
+	AddString(key string, value string)
+	AddInt64(key string, value int64)
+	AddFloat64(key string, value float64)
+	AddBool(key string, value bool)
+	AddDuration(key string, value time.Duration)
+	AddTime(key string, value time.Time)
+	AddObject(key string, value ObjectMarshaler) error
+	AddArray(key string, value ArrayMarshaler) error
+
+	// AddReflected adds a value of any other type, output by reflection.
+	AddReflected(key string, value interface{}) error
+}
+
+// ArrayEncoder receives the values of an ArrayMarshaler.
+type ArrayEncoder interface {
+	AppendString(value string)
+	AppendInt64(value int64)
+	AppendFloat64(value float64)
+	AppendBool(value bool)
+	AppendDuration(value time.Duration)
+	AppendTime(value time.Time)
+	AppendObject(value ObjectMarshaler) error
+	AppendArray(value ArrayMarshaler) error
+
+	// AppendReflected appends a value of any other type, output by reflection.
+	AppendReflected(value interface{}) error
+}
+
+// FlattenObject passes the values of the object to the visitor under dotted keys prefixed by the
+// given key, e.g. user.name, and elements of arrays under their index, e.g. user.roles.0. It is
+// meant for handlers of flat formats.
+func FlattenObject(key string, value ObjectMarshaler, v FieldVisitor) error {
+	return value.MarshalLogObject(&flatobject{prefix: key, visitor: v})
+}
+
+// FlattenArray passes the values of the array to the visitor under dotted keys prefixed by the
+// given key, see FlattenObject.
+func FlattenArray(key string, value ArrayMarshaler, v FieldVisitor) error {
+	return value.MarshalLogArray(&flatarray{prefix: key, visitor: v})
+}
+
+func flattenReflected(key string, value interface{}, v FieldVisitor) error {
+	switch m := value.(type) {
+	case ObjectMarshaler:
+		return FlattenObject(key, m, v)
+	case ArrayMarshaler:
+		return FlattenArray(key, m, v)
+	}
+	visit(key, value, v)
+	return nil
+}
+
+type flatobject struct {
+	prefix  string
+	visitor FieldVisitor
+}
+
+func (o *flatobject) key(key string) string {
+	return o.prefix + "." + key
+}
+
+func (o *flatobject) AddString(key string, value string) {
+	o.visitor.VisitString(o.key(key), value)
+}
+
+func (o *flatobject) AddInt64(key string, value int64) {
+	o.visitor.VisitInt64(o.key(key), value)
+}
+
+func (o *flatobject) AddFloat64(key string, value float64) {
+	o.visitor.VisitFloat64(o.key(key), value)
+}
+
+func (o *flatobject) AddBool(key string, value bool) {
+	o.visitor.VisitBool(o.key(key), value)
+}
+
+func (o *flatobject) AddDuration(key string, value time.Duration) {
+	o.visitor.VisitDuration(o.key(key), value)
+}
+
+func (o *flatobject) AddTime(key string, value time.Time) {
+	o.visitor.VisitTime(o.key(key), value)
+}
+
+func (o *flatobject) AddObject(key string, value ObjectMarshaler) error {
+	return FlattenObject(o.key(key), value, o.visitor)
+}
+
+func (o *flatobject) AddArray(key string, value ArrayMarshaler) error {
+	return FlattenArray(o.key(key), value, o.visitor)
+}
+
+func (o *flatobject) AddReflected(key string, value interface{}) error {
+	return flattenReflected(o.key(key), value, o.visitor)
+}
+
+type flatarray struct {
+	prefix  string
+	visitor FieldVisitor
+	index   int
+}
+
+func (a *flatarray) key() string {
+	key := a.prefix + "." + strconv.Itoa(a.index)
+	a.index++
+	return key
+}
+
+func (a *flatarray) AppendString(value string) {
+	a.visitor.VisitString(a.key(), value)
+}
+
+func (a *flatarray) AppendInt64(value int64) {
+	a.visitor.VisitInt64(a.key(), value)
+}
+
+func (a *flatarray) AppendFloat64(value float64) {
+	a.visitor.VisitFloat64(a.key(), value)
+}
+
+func (a *flatarray) AppendBool(value bool) {
+	a.visitor.VisitBool(a.key(), value)
+}
+
+func (a *flatarray) AppendDuration(value time.Duration) {
+	a.visitor.VisitDuration(a.key(), value)
+}
+
+func (a *flatarray) AppendTime(value time.Time) {
+	a.visitor.VisitTime(a.key(), value)
+}
+
+func (a *flatarray) AppendObject(value ObjectMarshaler) error {
+	return FlattenObject(a.key(), value, a.visitor)
+}
+
+func (a *flatarray) AppendArray(value ArrayMarshaler) error {
+	return FlattenArray(a.key(), value, a.visitor)
+}
+
+func (a *flatarray) AppendReflected(value interface{}) error {
+	return flattenReflected(a.key(), value, a.visitor)
+}
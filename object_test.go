@@ -0,0 +1,84 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slog"
+	"testing"
+	"time"
+)
+
+type account struct {
+	name  string
+	roles []string
+}
+
+func (a account) MarshalLogObject(enc slog.ObjectEncoder) error {
+	enc.AddString("name", a.name)
+	enc.AddBool("admin", false)
+	return enc.AddArray("roles", slog.ArrayMarshalerFunc(func(arr slog.ArrayEncoder) error {
+		for _, role := range a.roles {
+			arr.AppendString(role)
+		}
+		return nil
+	}))
+}
+
+func TestFlattenObject_dottedKeys_success(t *testing.T) {
+	v := &recordingvisitor{visited: make(map[string]string)}
+	obj := slog.ObjectMarshalerFunc(func(enc slog.ObjectEncoder) error {
+		enc.AddInt64("id", 5)
+		enc.AddDuration("age", time.Minute)
+		if err := enc.AddObject("account", account{name: "jdoe", roles: []string{"dev", "ops"}}); err != nil {
+			return err
+		}
+		return enc.AddReflected("tags", []int{1})
+	})
+	if err := slog.FlattenObject("user", obj, v); err != nil {
+		t.Error(err)
+	}
+	expected := map[string]string{
+		"user.id":              "int64:5",
+		"user.age":             "duration:1m0s",
+		"user.account.name":    "string:jdoe",
+		"user.account.admin":   "bool:false",
+		"user.account.roles.0": "string:dev",
+		"user.account.roles.1": "string:ops",
+		"user.tags":            "object:[1]",
+	}
+	if len(v.visited) != len(expected) {
+		t.Errorf("unexpected fields, %v", v.visited)
+	}
+	for key, value := range expected {
+		if v.visited[key] != value {
+			t.Errorf("unexpected value for %v, %v", key, v.visited[key])
+		}
+	}
+}
+
+func TestFlattenArray_nested_success(t *testing.T) {
+	v := &recordingvisitor{visited: make(map[string]string)}
+	arr := slog.ArrayMarshalerFunc(func(enc slog.ArrayEncoder) error {
+		enc.AppendInt64(1)
+		enc.AppendObject(account{name: "jdoe"})
+		return enc.AppendReflected(account{name: "other"})
+	})
+	if err := slog.FlattenArray("list", arr, v); err != nil {
+		t.Error(err)
+	}
+	if v.visited["list.0"] != "int64:1" || v.visited["list.1.name"] != "string:jdoe" || v.visited["list.2.name"] != "string:other" {
+		t.Errorf("unexpected fields, %v", v.visited)
+	}
+}
+
+func TestFlattenObject_onMarshalerError_error(t *testing.T) {
+	v := &recordingvisitor{visited: make(map[string]string)}
+	obj := slog.ObjectMarshalerFunc(func(enc slog.ObjectEncoder) error {
+		return errors.New("boom")
+	})
+	if err := slog.FlattenObject("obj", obj, v); err == nil || err.Error() != "boom" {
+		t.Errorf("expected error, %v", err)
+	}
+}
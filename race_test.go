@@ -0,0 +1,102 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/basic"
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// The tests below reconfigure the factory and handlers while logging and are meant to be run
+// with the race detector: go test -race
+
+func underload(t *testing.T, lf slog.LogFactory, reconfigure ...func(i int)) {
+	var wg, started sync.WaitGroup
+	stop := make(chan bool)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		started.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			logger := lf.WithContext("ctx"+strconv.Itoa(g%2)).WithField("g", g)
+			for n := 0; ; n++ {
+				if n == 1 {
+					started.Done()
+				}
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				logger.Debugf("debug %v", g)
+				err := errors.New("err")
+				logger.Info("info").Trace(&err)
+				lf.WithContext("ctx" + strconv.Itoa(g)).Warn("warn")
+			}
+		}(g)
+	}
+	started.Wait()
+	for i := 0; i < 20; i++ {
+		for _, r := range reconfigure {
+			r(i)
+		}
+		runtime.Gosched()
+	}
+	close(stop)
+	wg.Wait()
+	if err := lf.Flush(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRace_factoryReconfiguration_success(t *testing.T) {
+	ch := &counthandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(ch)
+	underload(t, lf,
+		func(i int) {
+			lf.SetLevel(slf.Level(i%3), "ctx0")
+			lf.SetLevel(slf.Level((i + 1) % 3))
+		},
+		func(i int) {
+			lf.SetCallerInfo(slf.CallerInfo(i%3), "ctx1")
+			lf.SetCallerInfo(slf.CallerInfo((i + 1) % 3))
+		},
+		func(i int) {
+			lf.SetConcurrent(i%2 == 0)
+		},
+		func(i int) {
+			lf.SetFields(slf.Fields{"i": i})
+			lf.SetFields(slf.Fields{"i": i}, "ctx0")
+		},
+		func(i int) {
+			if i%10 == 0 {
+				lf.AddEntryHandler(&counthandler{})
+				lf.Use(func(e slog.Entry) (slog.Entry, bool) { return e, true })
+			}
+		})
+	if ch.count == 0 {
+		t.Error("expected entries")
+	}
+}
+
+func TestRace_basicReconfiguration_success(t *testing.T) {
+	h := basic.New()
+	h.SetWriter(ioutil.Discard)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	underload(t, lf, func(i int) {
+		h.SetWriter(ioutil.Discard)
+		h.SetTemplate(basic.StandardTextTemplate + strconv.Itoa(i))
+		h.SetTimeFormat("15:04:05." + strconv.Itoa(i))
+		h.SetColors(map[slf.Level]int{slf.LevelInfo: i})
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"fmt"
+	"github.com/ventu-io/slf"
+	"os"
+	"runtime/debug"
+)
+
+// StackField defines the key for the stack trace of a recovered panic.
+const StackField = "stack"
+
+// PanicAction defines what happens to a recovered panic after it has been logged.
+type PanicAction int
+
+const (
+	// PanicContinue swallows the panic.
+	PanicContinue PanicAction = iota
+
+	// PanicRepanic panics again with the recovered value.
+	PanicRepanic
+
+	// PanicExit exits the process with status 1.
+	PanicExit
+)
+
+// RecoverAndLog recovers a panic and logs the recovered value at the panic level with the stack
+// trace under the stack key and the fields of the logger, a recovered error becomes the entry
+// error. Before re-panicking or exiting entries are flushed if the logger was delivered by a slog
+// log factory. It must be deferred directly:
+//
+//	defer slog.RecoverAndLog(logger, slog.PanicRepanic)
+func RecoverAndLog(log slf.StructuredLogger, action PanicAction) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	l := log.WithField(StackField, string(debug.Stack())).WithCaller(slf.CallerNone)
+	if err, ok := r.(error); ok {
+		l.WithError(err).Log(slf.LevelPanic, fmt.Sprintf("panic: %v", r))
+	} else {
+		l.Log(slf.LevelPanic, fmt.Sprintf("panic: %v", r))
+	}
+	if action == PanicContinue {
+		return
+	}
+	if log, ok := log.(*logger); ok {
+		log.rootLogger.factory.Flush()
+	}
+	if action == PanicExit {
+		os.Exit(1)
+	}
+	panic(r)
+}
+
+// Go runs the function in a new goroutine recovering and logging a panic as RecoverAndLog.
+func Go(log slf.StructuredLogger, action PanicAction, fn func()) {
+	go func() {
+		defer RecoverAndLog(log, action)
+		fn()
+	}()
+}
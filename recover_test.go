@@ -0,0 +1,87 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"strings"
+	"testing"
+)
+
+func TestRecoverAndLog_continue_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	func() {
+		defer slog.RecoverAndLog(lf.WithContext("ctx").WithField("key", "value"), slog.PanicContinue)
+		panic("boom")
+	}()
+	if len(th.entries) != 1 {
+		t.Fatalf("expected 1 entry, %v", th.entries)
+	}
+	e := th.entries[0]
+	fields := e.Fields()
+	if e.Level() != slf.LevelPanic || e.Message() != "panic: boom" || e.Error() != nil || fields["key"] != "value" {
+		t.Errorf("unexpected entry, %v %v %v", e.Level(), e.Message(), fields)
+	}
+	if stack, _ := fields[slog.StackField].(string); !strings.Contains(stack, "recover_test.go") {
+		t.Errorf("expected stack, %v", stack)
+	}
+}
+
+func TestRecoverAndLog_noPanic_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	func() {
+		defer slog.RecoverAndLog(lf.WithContext("ctx"), slog.PanicRepanic)
+	}()
+	if len(th.entries) != 0 {
+		t.Errorf("expected no entries, %v", th.entries)
+	}
+}
+
+func TestRecoverAndLog_repanic_success(t *testing.T) {
+	th := &flushhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+
+	err := errors.New("boom")
+	func() {
+		defer func() {
+			if r := recover(); r != err {
+				t.Errorf("expected repanic with the recovered value, %v", r)
+			}
+		}()
+		defer slog.RecoverAndLog(lf.WithContext("ctx"), slog.PanicRepanic)
+		panic(err)
+	}()
+	// handled concurrently, but flushed before repanicking
+	if len(th.entries) != 1 || th.flushed != 1 {
+		t.Fatalf("expected 1 flushed entry, %v %v", len(th.entries), th.flushed)
+	}
+	if e := th.entries[0]; e.Error() != err || e.Message() != "panic: boom" {
+		t.Errorf("unexpected entry, %v %v", e.Error(), e.Message())
+	}
+}
+
+func TestGo_success(t *testing.T) {
+	i := &interceptor{entry: make(chan slog.Entry, 1)}
+	lf := slog.New()
+	lf.AddEntryHandler(i)
+	lf.SetConcurrent(false)
+
+	slog.Go(lf.WithContext("ctx"), slog.PanicContinue, func() {
+		panic("boom")
+	})
+	if e := <-i.entry; e.Level() != slf.LevelPanic || e.Message() != "panic: boom" {
+		t.Errorf("unexpected entry, %v %v", e.Level(), e.Message())
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package redact
+
+import (
+	"fmt"
+	"github.com/ventu-io/slog"
+	"time"
+)
+
+// change represents a field to be set to a redacted value or to be dropped.
+type change struct {
+	key   string
+	value interface{}
+	drop  bool
+}
+
+// redactor collects the changes to the fields of an entry, leaving other fields, typed ones in
+// particular, untouched.
+type redactor struct {
+	rules   *rules
+	changes []change
+}
+
+// masked records the change if the key matches a key rule.
+func (v *redactor) masked(key string, value interface{}) bool {
+	strategy, ok := v.rules.key(key)
+	if !ok {
+		return false
+	}
+	if strategy == Drop {
+		v.changes = append(v.changes, change{key: key, drop: true})
+	} else {
+		v.changes = append(v.changes, change{key: key, value: v.rules.mask(fmt.Sprint(value), strategy)})
+	}
+	return true
+}
+
+func (v *redactor) VisitString(key string, value string) {
+	if !v.masked(key, value) {
+		if text, changed := v.rules.text(value); changed {
+			v.changes = append(v.changes, change{key: key, value: text})
+		}
+	}
+}
+
+func (v *redactor) VisitInt64(key string, value int64) {
+	v.masked(key, value)
+}
+
+func (v *redactor) VisitFloat64(key string, value float64) {
+	v.masked(key, value)
+}
+
+func (v *redactor) VisitBool(key string, value bool) {
+	v.masked(key, value)
+}
+
+func (v *redactor) VisitDuration(key string, value time.Duration) {
+	v.masked(key, value)
+}
+
+func (v *redactor) VisitTime(key string, value time.Time) {
+	v.masked(key, value)
+}
+
+func (v *redactor) VisitError(key string, value error) {
+	v.VisitObject(key, value)
+}
+
+func (v *redactor) VisitObject(key string, value interface{}) {
+	if !v.masked(key, value) {
+		if res, changed := v.rules.value(value); changed {
+			v.changes = append(v.changes, change{key: key, value: res})
+		}
+	}
+}
+
+// object represents an object marshaler redacting the keys and values of the wrapped one.
+type object struct {
+	rules *rules
+	value slog.ObjectMarshaler
+}
+
+func (o object) MarshalLogObject(enc slog.ObjectEncoder) error {
+	return o.value.MarshalLogObject(&objectencoder{rules: o.rules, enc: enc})
+}
+
+// array represents an array marshaler redacting the values of the wrapped one.
+type array struct {
+	rules *rules
+	value slog.ArrayMarshaler
+}
+
+func (a array) MarshalLogArray(enc slog.ArrayEncoder) error {
+	return a.value.MarshalLogArray(&arrayencoder{rules: a.rules, enc: enc})
+}
+
+type objectencoder struct {
+	rules *rules
+	enc   slog.ObjectEncoder
+}
+
+// masked adds the masked value if the key matches a key rule.
+func (o *objectencoder) masked(key string, value interface{}) bool {
+	strategy, ok := o.rules.key(key)
+	if !ok {
+		return false
+	}
+	if strategy != Drop {
+		o.enc.AddString(key, o.rules.mask(fmt.Sprint(value), strategy))
+	}
+	return true
+}
+
+func (o *objectencoder) AddString(key string, value string) {
+	if !o.masked(key, value) {
+		text, _ := o.rules.text(value)
+		o.enc.AddString(key, text)
+	}
+}
+
+func (o *objectencoder) AddInt64(key string, value int64) {
+	if !o.masked(key, value) {
+		o.enc.AddInt64(key, value)
+	}
+}
+
+func (o *objectencoder) AddFloat64(key string, value float64) {
+	if !o.masked(key, value) {
+		o.enc.AddFloat64(key, value)
+	}
+}
+
+func (o *objectencoder) AddBool(key string, value bool) {
+	if !o.masked(key, value) {
+		o.enc.AddBool(key, value)
+	}
+}
+
+func (o *objectencoder) AddDuration(key string, value time.Duration) {
+	if !o.masked(key, value) {
+		o.enc.AddDuration(key, value)
+	}
+}
+
+func (o *objectencoder) AddTime(key string, value time.Time) {
+	if !o.masked(key, value) {
+		o.enc.AddTime(key, value)
+	}
+}
+
+func (o *objectencoder) AddObject(key string, value slog.ObjectMarshaler) error {
+	if o.masked(key, value) {
+		return nil
+	}
+	return o.enc.AddObject(key, object{rules: o.rules, value: value})
+}
+
+func (o *objectencoder) AddArray(key string, value slog.ArrayMarshaler) error {
+	if o.masked(key, value) {
+		return nil
+	}
+	return o.enc.AddArray(key, array{rules: o.rules, value: value})
+}
+
+func (o *objectencoder) AddReflected(key string, value interface{}) error {
+	if o.masked(key, value) {
+		return nil
+	}
+	res, _ := o.rules.value(value)
+	return o.enc.AddReflected(key, res)
+}
+
+type arrayencoder struct {
+	rules *rules
+	enc   slog.ArrayEncoder
+}
+
+func (a *arrayencoder) AppendString(value string) {
+	text, _ := a.rules.text(value)
+	a.enc.AppendString(text)
+}
+
+func (a *arrayencoder) AppendInt64(value int64) {
+	a.enc.AppendInt64(value)
+}
+
+func (a *arrayencoder) AppendFloat64(value float64) {
+	a.enc.AppendFloat64(value)
+}
+
+func (a *arrayencoder) AppendBool(value bool) {
+	a.enc.AppendBool(value)
+}
+
+func (a *arrayencoder) AppendDuration(value time.Duration) {
+	a.enc.AppendDuration(value)
+}
+
+func (a *arrayencoder) AppendTime(value time.Time) {
+	a.enc.AppendTime(value)
+}
+
+func (a *arrayencoder) AppendObject(value slog.ObjectMarshaler) error {
+	return a.enc.AppendObject(object{rules: a.rules, value: value})
+}
+
+func (a *arrayencoder) AppendArray(value slog.ArrayMarshaler) error {
+	return a.enc.AppendArray(array{rules: a.rules, value: value})
+}
+
+func (a *arrayencoder) AppendReflected(value interface{}) error {
+	res, _ := a.rules.value(value)
+	return a.enc.AppendReflected(res)
+}
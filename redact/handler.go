@@ -0,0 +1,282 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+// Package redact provides an entry handler wrapper and middleware for slog keeping sensitive data
+// such as passwords, tokens or personal information out of the log. Field values are masked by
+// key name patterns, and any text in field values, messages and errors by value patterns. Entries
+// are redacted into copies, the original entry and its field map are never modified.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Strategy defines how a sensitive value is masked.
+type Strategy int
+
+const (
+	// Drop removes the field with a matching key or the matching text from a value.
+	Drop Strategy = iota
+
+	// Replace substitutes the value or the matching text with the replacement string.
+	Replace
+
+	// Hash substitutes the value or the matching text with its salted SHA-256 hash (truncated
+	// to 16 hex digits), which keeps equal values correlated without revealing them.
+	Hash
+
+	// Partial masks all but the last few characters of the value or the matching text.
+	Partial
+)
+
+const (
+	// StandardReplacement represents the replacement string used by default.
+	StandardReplacement = "[REDACTED]"
+
+	// StandardVisible represents the number of trailing characters left visible by Partial.
+	StandardVisible = 4
+
+	mask = '*'
+)
+
+var (
+	// CreditCard matches credit card numbers with optional space or dash separators.
+	CreditCard = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+	// Email matches e-mail addresses.
+	Email = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+	// JWT matches JSON web tokens.
+	JWT = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// Handler represents an entry handler wrapper redacting entries before forwarding them to the
+// wrapped handler. The handler can be reconfigured and used concurrently.
+type Handler struct {
+	sync.RWMutex
+	handler slog.EntryHandler
+	rules
+}
+
+// rules represents the redaction settings, copied from the handler for every entry as marshalers
+// are redacted when output, after the entry has been handed on.
+type rules struct {
+	keys        []keyrule
+	values      []valuerule
+	replacement string
+	salt        []byte
+	visible     int
+}
+
+type keyrule struct {
+	pattern  string
+	strategy Strategy
+}
+
+type valuerule struct {
+	re       *regexp.Regexp
+	strategy Strategy
+}
+
+// New constructs a new redacting handler wrapping the given one.
+func New(handler slog.EntryHandler) *Handler {
+	return &Handler{
+		handler: handler,
+		rules:   rules{replacement: StandardReplacement, visible: StandardVisible},
+	}
+}
+
+// AddKey adds a case-insensitive glob pattern (as in path.Match, e.g. "*password*") for keys of
+// fields whose values are masked as a whole using the given strategy. Keys of nested field maps
+// are matched as well. The first matching pattern applies.
+func (h *Handler) AddKey(pattern string, strategy Strategy) error {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	h.Lock()
+	h.keys = append(h.keys, keyrule{pattern: pattern, strategy: strategy})
+	h.Unlock()
+	return nil
+}
+
+// AddValue adds a regular expression for text to be masked using the given strategy in string
+// and error field values, also within field maps and ObjectMarshaler and ArrayMarshaler values,
+// in the message and in the error of entries. Values of other types are only masked by key.
+func (h *Handler) AddValue(re *regexp.Regexp, strategy Strategy) {
+	h.Lock()
+	h.values = append(h.values, valuerule{re: re, strategy: strategy})
+	h.Unlock()
+}
+
+// SetReplacement defines the replacement string for the Replace strategy (default: [REDACTED]).
+func (h *Handler) SetReplacement(replacement string) {
+	h.Lock()
+	h.replacement = replacement
+	h.Unlock()
+}
+
+// SetSalt defines the salt prepended to values hashed by the Hash strategy (default: none).
+func (h *Handler) SetSalt(salt []byte) {
+	h.Lock()
+	h.salt = append([]byte{}, salt...)
+	h.Unlock()
+}
+
+// SetVisible defines the number of trailing characters left visible by the Partial strategy
+// (default: 4). Values not longer than twice that number are masked completely.
+func (h *Handler) SetVisible(n int) {
+	h.Lock()
+	h.visible = n
+	h.Unlock()
+}
+
+// Handle forwards a redacted copy of the log entry to the wrapped handler.
+func (h *Handler) Handle(e slog.Entry) error {
+	return h.handler.Handle(h.Redact(e))
+}
+
+// Flush flushes the wrapped handler if it implements slog.Flusher.
+func (h *Handler) Flush() error {
+	if f, ok := h.handler.(slog.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Middleware redacts log entries as a slog.Middleware, so that entries are redacted once before
+// any entry handler sees them, e.g. lf.Use(h.Middleware).
+func (h *Handler) Middleware(e slog.Entry) (slog.Entry, bool) {
+	return h.Redact(e), true
+}
+
+// Redact returns a redacted copy of the log entry, or the entry itself if nothing was redacted.
+// Typed fields are retained unless redacted. Values implementing slog.ObjectMarshaler or
+// slog.ArrayMarshaler are replaced by marshalers applying the rules to their keys and values.
+func (h *Handler) Redact(e slog.Entry) slog.Entry {
+	h.RLock()
+	r := h.rules
+	h.RUnlock()
+	if len(r.keys) == 0 && len(r.values) == 0 {
+		return e
+	}
+	message, mchanged := r.text(e.Message())
+	err, echanged := e.Error(), false
+	if err != nil {
+		var text string
+		if text, echanged = r.text(err.Error()); echanged {
+			err = errors.New(text)
+		}
+	}
+	v := &redactor{rules: &r}
+	slog.VisitFields(e, v)
+	if !mchanged && !echanged && len(v.changes) == 0 {
+		return e
+	}
+	d := slog.Derive(e).SetMessage(message).SetError(err)
+	for _, c := range v.changes {
+		if c.drop {
+			d.DeleteField(c.key)
+		} else {
+			d.SetField(c.key, c.value)
+		}
+	}
+	return d
+}
+
+// fields returns a redacted copy of the field map or the map itself if unchanged.
+func (r *rules) fields(fields map[string]interface{}) (map[string]interface{}, bool) {
+	res := make(map[string]interface{}, len(fields))
+	changed := false
+	for key, value := range fields {
+		if strategy, ok := r.key(key); ok {
+			changed = true
+			if strategy != Drop {
+				res[key] = r.mask(fmt.Sprint(value), strategy)
+			}
+			continue
+		}
+		v, vchanged := r.value(value)
+		res[key] = v
+		changed = changed || vchanged
+	}
+	if !changed {
+		return fields, false
+	}
+	return res, true
+}
+
+func (r *rules) key(key string) (Strategy, bool) {
+	key = strings.ToLower(key)
+	for _, rule := range r.keys {
+		if ok, _ := path.Match(rule.pattern, key); ok {
+			return rule.strategy, true
+		}
+	}
+	return Drop, false
+}
+
+func (r *rules) value(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return r.text(v)
+	case error:
+		if text, changed := r.text(v.Error()); changed {
+			return text, true
+		}
+	case map[string]interface{}:
+		return r.fields(v)
+	case slf.Fields:
+		if res, changed := r.fields(v); changed {
+			return slf.Fields(res), true
+		}
+	case slog.ObjectMarshaler:
+		return object{rules: r, value: v}, true
+	case slog.ArrayMarshaler:
+		return array{rules: r, value: v}, true
+	}
+	return value, false
+}
+
+// text masks all matches of value patterns in the text.
+func (r *rules) text(text string) (string, bool) {
+	res := text
+	for _, rule := range r.values {
+		strategy := rule.strategy
+		res = rule.re.ReplaceAllStringFunc(res, func(match string) string {
+			return r.mask(match, strategy)
+		})
+	}
+	return res, res != text
+}
+
+func (r *rules) mask(value string, strategy Strategy) string {
+	switch strategy {
+	case Replace:
+		return r.replacement
+	case Hash:
+		sum := sha256.Sum256(append(append([]byte{}, r.salt...), value...))
+		return hex.EncodeToString(sum[:8])
+	case Partial:
+		n := utf8.RuneCountInString(value)
+		if n <= 2*r.visible {
+			return strings.Repeat(string(mask), n)
+		}
+		runes := []rune(value)
+		for i := 0; i < n-r.visible; i++ {
+			runes[i] = mask
+		}
+		return string(runes)
+	}
+	return ""
+}
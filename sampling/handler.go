@@ -0,0 +1,282 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+// Package sampling provides an entry handler wrapper for slog that thins out high-volume logging
+// before it reaches the wrapped handler. It supports sampling of repeated messages (first N, then
+// every Mth per interval), token-bucket rate limiting per context and probabilistic sampling per
+// level. Dropped entries are counted and reported via a summary entry once per interval.
+package sampling
+
+import (
+	"fmt"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// StandardInterval represents the sampling and summary interval used by default.
+	StandardInterval = time.Second
+
+	// SummaryContext defines the context of summary entries reporting dropped entries.
+	SummaryContext = "sampling"
+
+	// DroppedField defines the key for the total number of dropped entries in a summary entry.
+	DroppedField = "dropped"
+
+	// SampledField defines the key for the number of entries dropped by message sampling.
+	SampledField = "dropped_sampled"
+
+	// RateLimitedField defines the key for the number of entries dropped by rate limiting.
+	RateLimitedField = "dropped_ratelimited"
+
+	// ProbabilityField defines the key for the number of entries dropped by level probability.
+	ProbabilityField = "dropped_probability"
+
+	summaryMessage = "dropped log entries"
+)
+
+// Handler represents an entry handler wrapper dropping entries according to the configured
+// sampling rules and forwarding the remaining ones to the wrapped handler. All sampling is
+// disabled by default. The handler synchronises internally and can be used concurrently.
+type Handler struct {
+	sync.Mutex
+	handler       slog.EntryHandler
+	clock         slog.Clock
+	interval      time.Duration
+	first         int
+	thereafter    int
+	counters      map[counterkey]int
+	start         time.Time
+	limit         limit
+	limits        map[string]limit
+	buckets       map[string]*bucket
+	probabilities map[slf.Level]float64
+	random        *rand.Rand
+	dropped       counts
+	timer         *time.Timer
+}
+
+type counterkey struct {
+	level   slf.Level
+	message string
+}
+
+type limit struct {
+	rate  float64
+	burst int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type counts struct {
+	sampled     int
+	ratelimited int
+	probability int
+}
+
+func (c counts) total() int {
+	return c.sampled + c.ratelimited + c.probability
+}
+
+// New constructs a new sampling handler wrapping the given one.
+func New(handler slog.EntryHandler) *Handler {
+	return &Handler{
+		handler:       handler,
+		clock:         slog.SystemClock,
+		interval:      StandardInterval,
+		counters:      make(map[counterkey]int),
+		limits:        make(map[string]limit),
+		buckets:       make(map[string]*bucket),
+		probabilities: make(map[slf.Level]float64),
+		random:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetInterval defines the interval over which repeated messages are counted and after which
+// a summary of dropped entries is output (default: 1s). A non-positive interval is rejected.
+func (h *Handler) SetInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("sampling: invalid interval %v", interval)
+	}
+	h.Lock()
+	h.interval = interval
+	h.Unlock()
+	return nil
+}
+
+// SetClock sets the clock measuring the sampling interval and rate limits and supplying the time
+// stamp of summary entries, the system clock if nil.
+func (h *Handler) SetClock(clock slog.Clock) {
+	if clock == nil {
+		clock = slog.SystemClock
+	}
+	h.Lock()
+	h.clock = clock
+	h.start = time.Time{}
+	h.buckets = make(map[string]*bucket)
+	h.Unlock()
+}
+
+// SetSampling enables sampling of repeated messages: within every interval the first entries
+// with the same level and message are passed on and thereafter only every Mth one. A zero first
+// disables message sampling, a zero thereafter drops all entries after the first ones.
+func (h *Handler) SetSampling(first, thereafter int) {
+	h.Lock()
+	h.first = first
+	h.thereafter = thereafter
+	h.Unlock()
+}
+
+// SetRateLimit limits the rate of entries per second to given contexts, or to every context if
+// none given, permitting bursts of up to burst entries. A non-positive rate removes the limit.
+func (h *Handler) SetRateLimit(rate float64, burst int, contexts ...string) {
+	if burst < 1 {
+		burst = 1
+	}
+	h.Lock()
+	defer h.Unlock()
+	if len(contexts) == 0 {
+		h.limit = limit{rate: rate, burst: burst}
+		h.buckets = make(map[string]*bucket)
+		return
+	}
+	for _, context := range contexts {
+		h.limits[context] = limit{rate: rate, burst: burst}
+		delete(h.buckets, context)
+	}
+}
+
+// SetProbability defines the probability, between 0 and 1, with which entries of the given level
+// are passed on. Levels without a probability are always passed on.
+func (h *Handler) SetProbability(level slf.Level, probability float64) {
+	h.Lock()
+	h.probabilities[level] = probability
+	h.Unlock()
+}
+
+// Handle forwards the log entry to the wrapped handler unless it is dropped by sampling.
+func (h *Handler) Handle(e slog.Entry) error {
+	if !h.pass(e) {
+		return nil
+	}
+	return h.handler.Handle(e)
+}
+
+// Flush outputs the summary of entries dropped since the last summary, if any, without waiting
+// for the end of the interval, and flushes the wrapped handler if it implements slog.Flusher.
+func (h *Handler) Flush() error {
+	h.Lock()
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	e := h.summary()
+	h.Unlock()
+	if e != nil {
+		if err := h.handler.Handle(e); err != nil {
+			return err
+		}
+	}
+	if f, ok := h.handler.(slog.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (h *Handler) pass(e slog.Entry) bool {
+	h.Lock()
+	defer h.Unlock()
+	now := h.clock.Now()
+
+	if p, ok := h.probabilities[e.Level()]; ok && h.random.Float64() >= p {
+		h.drop(&h.dropped.probability)
+		return false
+	}
+	if h.first > 0 {
+		if h.start.IsZero() || now.Sub(h.start) >= h.interval {
+			h.counters = make(map[counterkey]int)
+			h.start = now
+		}
+		key := counterkey{level: e.Level(), message: e.Message()}
+		n := h.counters[key] + 1
+		h.counters[key] = n
+		if n > h.first && (h.thereafter <= 0 || (n-h.first)%h.thereafter != 0) {
+			h.drop(&h.dropped.sampled)
+			return false
+		}
+	}
+	context, _ := e.Fields()[slog.ContextField].(string)
+	l, ok := h.limits[context]
+	if !ok {
+		l = h.limit
+	}
+	if l.rate > 0 {
+		b, ok := h.buckets[context]
+		if !ok {
+			b = &bucket{tokens: float64(l.burst), last: now}
+			h.buckets[context] = b
+		}
+		if !b.take(now, l) {
+			h.drop(&h.dropped.ratelimited)
+			return false
+		}
+	}
+	return true
+}
+
+// drop counts a dropped entry and schedules the summary (must be called under lock).
+func (h *Handler) drop(counter *int) {
+	*counter++
+	if h.timer == nil {
+		h.timer = time.AfterFunc(h.interval, h.report)
+	}
+}
+
+func (h *Handler) report() {
+	h.Lock()
+	e := h.summary()
+	h.Unlock()
+	if e == nil {
+		return
+	}
+	if err := h.handler.Handle(e); err != nil {
+		// fall back to standard logging to output entry handler error
+		slog.ReportHandlerError(err)
+	}
+}
+
+// summary constructs the summary entry and resets the counts (must be called under lock).
+func (h *Handler) summary() slog.Entry {
+	h.timer = nil
+	dropped := h.dropped
+	h.dropped = counts{}
+	if dropped.total() == 0 {
+		return nil
+	}
+	fields := map[string]interface{}{
+		slog.ContextField: SummaryContext,
+		DroppedField:      dropped.total(),
+		SampledField:      dropped.sampled,
+		RateLimitedField:  dropped.ratelimited,
+		ProbabilityField:  dropped.probability,
+	}
+	return slog.NewEntry(h.clock.Now(), slf.LevelWarn, summaryMessage, nil, fields)
+}
+
+func (b *bucket) take(now time.Time, l limit) bool {
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package sampling_test
+
+import (
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/sampling"
+	"github.com/ventu-io/slog/slogtest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testhandler struct {
+	sync.Mutex
+	entries []slog.Entry
+}
+
+func (th *testhandler) Handle(entry slog.Entry) error {
+	th.Lock()
+	th.entries = append(th.entries, entry)
+	th.Unlock()
+	return nil
+}
+
+func (th *testhandler) messages() []string {
+	th.Lock()
+	defer th.Unlock()
+	res := []string{}
+	for _, e := range th.entries {
+		res = append(res, e.Message())
+	}
+	return res
+}
+
+func TestHandler_noSampling_passesAll_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(sampling.New(th))
+	lf.SetConcurrent(false)
+
+	for i := 0; i < 100; i++ {
+		lf.WithContext("test").Info("msg")
+	}
+	if len(th.entries) != 100 {
+		t.Errorf("expected 100 entries, found %v", len(th.entries))
+	}
+}
+
+func TestHandler_firstThereafter_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetSampling(3, 10)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	for i := 0; i < 33; i++ {
+		lf.WithContext("test").Info("repeated")
+		lf.WithContext("test").Infof("unique%v", i)
+	}
+	counts := make(map[string]int)
+	for _, m := range th.messages() {
+		counts[m]++
+	}
+	// 1, 2, 3, 13, 23, 33
+	if counts["repeated"] != 6 {
+		t.Errorf("expected 6 repeated entries, found %v", counts["repeated"])
+	}
+	if len(counts) != 34 {
+		t.Errorf("expected all unique entries, found %v", len(counts))
+	}
+}
+
+func TestHandler_firstThereafter_resetsOnInterval_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetSampling(1, 0)
+	h.SetInterval(50 * time.Millisecond)
+	clock := slogtest.NewFakeClock(time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC))
+	h.SetClock(clock)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	logger := lf.WithContext("test")
+	logger.Info("repeated")
+	logger.Info("repeated")
+	clock.Add(60 * time.Millisecond)
+	logger.Info("repeated")
+	logger.Info("repeated")
+	repeated := 0
+	for _, m := range th.messages() {
+		if m == "repeated" {
+			repeated++
+		}
+	}
+	if repeated != 2 {
+		t.Errorf("expected 2 entries, %v", th.messages())
+	}
+}
+
+func TestHandler_rateLimitPerContext_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetRateLimit(1, 5, "limited")
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	for i := 0; i < 20; i++ {
+		lf.WithContext("limited").Infof("limited%v", i)
+		lf.WithContext("free").Infof("free%v", i)
+	}
+	limited := 0
+	for _, e := range th.entries {
+		if e.Fields()[slog.ContextField] == "limited" {
+			limited++
+		}
+	}
+	if limited != 5 {
+		t.Errorf("expected a burst of 5 entries, found %v", limited)
+	}
+	if len(th.entries) != 25 {
+		t.Errorf("expected all entries of free context, found %v", len(th.entries)-limited)
+	}
+}
+
+func TestHandler_rateLimitAllContexts_refills_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetRateLimit(100, 1)
+	clock := slogtest.NewFakeClock(time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC))
+	h.SetClock(clock)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	for i := 0; i < 3; i++ {
+		lf.WithContext("ctx" + strconv.Itoa(i)).Info("first")
+		lf.WithContext("ctx" + strconv.Itoa(i)).Info("second")
+	}
+	if len(th.entries) != 3 {
+		t.Errorf("expected one entry per context, found %v", len(th.entries))
+	}
+	clock.Add(20 * time.Millisecond)
+	lf.WithContext("ctx0").Info("third")
+	if len(th.entries) != 4 {
+		t.Errorf("expected bucket refilled, found %v", len(th.entries))
+	}
+}
+
+func TestHandler_probabilityPerLevel_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetProbability(slf.LevelDebug, 0)
+	h.SetProbability(slf.LevelInfo, 0.5)
+	h.SetProbability(slf.LevelWarn, 1)
+	lf := slog.New()
+	lf.SetLevel(slf.LevelDebug)
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	logger := lf.WithContext("test")
+	for i := 0; i < 1000; i++ {
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+	}
+	counts := make(map[string]int)
+	for _, m := range th.messages() {
+		counts[m]++
+	}
+	if counts["debug"] != 0 || counts["warn"] != 1000 {
+		t.Errorf("unexpected counts, %v", counts)
+	}
+	if counts["info"] < 400 || counts["info"] > 600 {
+		t.Errorf("unexpected info count, %v", counts["info"])
+	}
+}
+
+func TestHandler_flush_outputsSummary_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetSampling(1, 0)
+	h.SetProbability(slf.LevelWarn, 0)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	for i := 0; i < 5; i++ {
+		lf.WithContext("test").Info("info")
+		lf.WithContext("test").Warn("warn")
+	}
+	if err := h.Flush(); err != nil {
+		t.Error(err)
+	}
+	if len(th.entries) != 2 {
+		t.Fatalf("expected info and summary, %v", th.messages())
+	}
+	summary := th.entries[1]
+	if summary.Level() != slf.LevelWarn || summary.Fields()[slog.ContextField] != sampling.SummaryContext {
+		t.Errorf("unexpected summary, %v", summary)
+	}
+	fields := summary.Fields()
+	if fields[sampling.DroppedField] != 9 || fields[sampling.SampledField] != 4 || fields[sampling.ProbabilityField] != 5 {
+		t.Errorf("unexpected summary fields, %v", fields)
+	}
+	// counts are reset
+	if err := h.Flush(); err != nil {
+		t.Error(err)
+	}
+	if len(th.entries) != 2 {
+		t.Errorf("expected no further summary, %v", th.messages())
+	}
+}
+
+func TestHandler_summaryAfterInterval_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetSampling(1, 0)
+	h.SetInterval(20 * time.Millisecond)
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	lf.WithContext("test").Info("info")
+	lf.WithContext("test").Info("info")
+	time.Sleep(100 * time.Millisecond)
+	messages := th.messages()
+	if len(messages) != 2 || messages[1] != "dropped log entries" {
+		t.Errorf("expected summary, %v", messages)
+	}
+}
+
+func TestHandler_summaryTimeFromClock_success(t *testing.T) {
+	th := &testhandler{}
+	h := sampling.New(th)
+	h.SetSampling(1, 0)
+	tm := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	h.SetClock(slogtest.NewFakeClock(tm))
+	lf := slog.New()
+	lf.AddEntryHandler(h)
+	lf.SetConcurrent(false)
+
+	lf.WithContext("test").Info("info")
+	lf.WithContext("test").Info("info")
+	if err := h.Flush(); err != nil {
+		t.Error(err)
+	}
+	if len(th.entries) != 2 || !th.entries[1].Time().Equal(tm) {
+		t.Errorf("expected summary at clock time, %v", th.entries)
+	}
+}
+
+func TestHandler_setInterval_nonPositive_error(t *testing.T) {
+	h := sampling.New(&testhandler{})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := h.SetInterval(interval); err == nil {
+			t.Errorf("expected error for %v", interval)
+		}
+	}
+	if err := h.SetInterval(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slogtest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock represents a clock standing still unless moved explicitly, for deterministic entry
+// time stamps and trace durations when set on the log factory with SetClock. It is safe for
+// concurrent use.
+type FakeClock struct {
+	sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock constructs a fake clock showing the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now implements the slog.Clock interface, returning the current time of the clock and advancing
+// it by the step afterwards.
+func (c *FakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	res := c.now
+	c.now = c.now.Add(c.step)
+	return res
+}
+
+// Set sets the time of the clock.
+func (c *FakeClock) Set(now time.Time) {
+	c.Lock()
+	c.now = now
+	c.Unlock()
+}
+
+// Add moves the clock by the duration.
+func (c *FakeClock) Add(d time.Duration) {
+	c.Lock()
+	c.now = c.now.Add(d)
+	c.Unlock()
+}
+
+// SetStep sets the duration the clock advances by on every reading (default: 0).
+func (c *FakeClock) SetStep(step time.Duration) {
+	c.Lock()
+	c.step = step
+	c.Unlock()
+}
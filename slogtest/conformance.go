@@ -0,0 +1,323 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slogtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Record represents an entry as parsed back from the output of an entry handler.
+type Record struct {
+	Level   slf.Level
+	Message string
+	// the error text, empty if none
+	Error string
+	// field values as text, e.g. "5" for 5, "true" for true
+	Fields map[string]string
+}
+
+// ParseLevel returns the level for its string representation, e.g. for parsing handler output.
+func ParseLevel(s string) (slf.Level, error) {
+	for level := slf.LevelDebug; level <= slf.LevelFatal; level++ {
+		if level.String() == s {
+			return level, nil
+		}
+	}
+	return slf.LevelDebug, fmt.Errorf("slogtest: unknown level %q", s)
+}
+
+var levels = []slf.Level{slf.LevelDebug, slf.LevelInfo, slf.LevelWarn, slf.LevelError, slf.LevelPanic, slf.LevelFatal}
+
+// RunHandlerTests runs the conformance tests for entry handlers as subtests. For every subtest a
+// new handler is constructed to write into the given writer, and the output of the subtest is
+// parsed back into records, one per entry. The tests verify that handlers:
+//
+//   - output entries of every level with their message, and the error if any;
+//   - accept nil, empty and huge field maps;
+//   - accept field values of unusual types without panicking, either outputting the entry or
+//     returning an error;
+//   - output entries with typed fields produced by slog loggers;
+//   - can be called concurrently on entries sharing the field map;
+//   - never modify the field map of the entry.
+func RunHandlerTests(t *testing.T, newHandler func(w io.Writer) slog.EntryHandler, parse func(output []byte) ([]Record, error)) {
+	c := &conformance{newHandler: newHandler, parse: parse}
+	t.Run("levels", c.levels)
+	t.Run("errors", c.errors)
+	t.Run("empty fields", c.emptyfields)
+	t.Run("huge fields", c.hugefields)
+	t.Run("unusual values", c.unusualvalues)
+	t.Run("typed fields", c.typedfields)
+	t.Run("concurrent", c.concurrent)
+	t.Run("immutable fields", c.immutablefields)
+}
+
+type conformance struct {
+	newHandler func(w io.Writer) slog.EntryHandler
+	parse      func(output []byte) ([]Record, error)
+}
+
+// handle passes the entries to a new handler and returns the parsed records.
+func (c *conformance) handle(t *testing.T, entries ...slog.Entry) []Record {
+	t.Helper()
+	w := &syncwriter{}
+	h := c.newHandler(w)
+	for _, e := range entries {
+		if err := h.Handle(e); err != nil {
+			t.Fatalf("unexpected handler error, %v", err)
+		}
+	}
+	return c.records(t, w, len(entries))
+}
+
+func (c *conformance) records(t *testing.T, w *syncwriter, expected int) []Record {
+	t.Helper()
+	res, err := c.parse(w.bytes())
+	if err != nil {
+		t.Fatalf("unparsable output, %v: %q", err, w.bytes())
+	}
+	if len(res) != expected {
+		t.Fatalf("expected %v records, found %v: %q", expected, len(res), w.bytes())
+	}
+	return res
+}
+
+func (c *conformance) levels(t *testing.T) {
+	var entries []slog.Entry
+	for _, level := range levels {
+		entries = append(entries, entry(level, "message at "+level.String(), nil, map[string]interface{}{"key": "value"}))
+	}
+	for i, r := range c.handle(t, entries...) {
+		if r.Level != levels[i] || r.Message != entries[i].Message() || r.Fields["key"] != "value" {
+			t.Errorf("unexpected record for %v, %+v", levels[i], r)
+		}
+	}
+}
+
+func (c *conformance) errors(t *testing.T) {
+	err := errors.New(`failed: "quoted" and ünïcode`)
+	records := c.handle(t, entry(slf.LevelError, "without error", nil, nil),
+		entry(slf.LevelError, "with error", err, nil))
+	if r := records[0]; r.Message != "without error" || r.Error != "" {
+		t.Errorf("expected no error, %+v", r)
+	}
+	if r := records[1]; r.Message != "with error" || !strings.Contains(r.Error, err.Error()) {
+		t.Errorf("expected error, %+v", r)
+	}
+}
+
+func (c *conformance) emptyfields(t *testing.T) {
+	for i, r := range c.handle(t, entry(slf.LevelInfo, "nil", nil, nil),
+		entry(slf.LevelInfo, "empty", nil, map[string]interface{}{})) {
+		if r.Message != []string{"nil", "empty"}[i] || len(r.Fields) != 0 {
+			t.Errorf("unexpected record, %+v", r)
+		}
+	}
+}
+
+func (c *conformance) hugefields(t *testing.T) {
+	fields := make(map[string]interface{})
+	for i := 0; i < 1000; i++ {
+		fields[fmt.Sprintf("f%04d", i)] = i
+	}
+	fields["long"] = strings.Repeat("x", 64*1024)
+	r := c.handle(t, entry(slf.LevelInfo, "huge", nil, fields))[0]
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("f%04d", i)
+		if r.Fields[key] != strconv.Itoa(i) {
+			t.Fatalf("%v: expected %v, found %q", key, i, r.Fields[key])
+		}
+	}
+	if r.Fields["long"] != fields["long"] {
+		t.Errorf("expected long value of %v characters, found %v", 64*1024, len(r.Fields["long"]))
+	}
+}
+
+type object struct {
+	Name  string
+	Inner *object
+}
+
+type marshaler struct{}
+
+func (marshaler) MarshalLogObject(enc slog.ObjectEncoder) error {
+	enc.AddString("name", "value")
+	return nil
+}
+
+func unusualvalues() map[string]interface{} {
+	var nilobject *object
+	var nilerror error
+	return map[string]interface{}{
+		"nil":          nil,
+		"nil error":    nilerror,
+		"nil ptr":      nilobject,
+		"bytes":        []byte("bytes"),
+		"map":          map[string]interface{}{"a": 1, "b": []int{1, 2}},
+		"int keys":     map[int]string{1: "a"},
+		"slice":        []interface{}{1, "a", nil},
+		"struct":       object{Name: "a", Inner: &object{Name: "b"}},
+		"ptr":          &object{Name: "a"},
+		"nan":          math.NaN(),
+		"inf":          math.Inf(-1),
+		"int8":         int8(-8),
+		"uint64":       uint64(math.MaxUint64),
+		"complex":      complex(1, 2),
+		"time":         time.Date(2016, 1, 2, 3, 4, 5, 6, time.UTC),
+		"duration":     time.Minute,
+		"error":        errors.New("value error"),
+		"marshaler":    marshaler{},
+		"chan":         make(chan int),
+		"func":         func() {},
+		"empty key":    "",
+		"":             "empty key",
+		"unicode ключ": "ünïcode \"quoted\" \\ value",
+	}
+}
+
+func (c *conformance) unusualvalues(t *testing.T) {
+	for key, value := range unusualvalues() {
+		e := entry(slf.LevelInfo, "unusual", nil, map[string]interface{}{key: value})
+		w := &syncwriter{}
+		h := c.newHandler(w)
+		err := handlenopanic(h, e)
+		if _, ok := err.(panicerror); ok {
+			t.Errorf("%q: %v", key, err)
+			continue
+		}
+		if err != nil {
+			// refusing a value is acceptable, producing unparsable output is not
+			continue
+		}
+		if r := c.records(t, w, 1)[0]; r.Message != "unusual" {
+			t.Errorf("%q: unexpected record, %+v", key, r)
+		}
+	}
+}
+
+func (c *conformance) typedfields(t *testing.T) {
+	r := NewRecorder()
+	lf := slog.New()
+	lf.SetEntryHandlers(r)
+	lf.SetConcurrent(false)
+	logger := lf.WithContext("ctx").WithField("plain", "value").(slog.StructuredLogger)
+	logger.WithTypedFields(slog.String("s", "string"), slog.Int64("n", 5), slog.Bool("b", true)).Info("typed")
+	slog.Events(logger).Warn().Str("s", "event").Int("n", 6).Msg("event")
+	records := c.handle(t, r.Entries()...)
+	for i, expected := range []map[string]string{
+		{"plain": "value", "s": "string", "n": "5", "b": "true"},
+		{"plain": "value", "s": "event", "n": "6"},
+	} {
+		for key, value := range expected {
+			if records[i].Fields[key] != value {
+				t.Errorf("%v: expected %v, found %q", key, value, records[i].Fields[key])
+			}
+		}
+	}
+}
+
+func (c *conformance) concurrent(t *testing.T) {
+	const routines, count = 8, 50
+	fields := map[string]interface{}{"shared": "value", "map": map[string]interface{}{"a": 1}}
+	w := &syncwriter{}
+	h := c.newHandler(w)
+	var wg sync.WaitGroup
+	errs := make(chan error, routines*count)
+	for g := 0; g < routines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				if err := handlenopanic(h, entry(slf.LevelInfo, fmt.Sprintf("message %v-%v", g, i), nil, fields)); err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("unexpected handler error, %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, r := range c.records(t, w, routines*count) {
+		if seen[r.Message] || r.Fields["shared"] != "value" {
+			t.Errorf("unexpected record, %+v", r)
+		}
+		seen[r.Message] = true
+	}
+}
+
+func (c *conformance) immutablefields(t *testing.T) {
+	for _, fields := range []func() map[string]interface{}{
+		func() map[string]interface{} {
+			return map[string]interface{}{"key": "value", "n": 1, slog.ContextField: "ctx", slog.CallerField: "file.go:1"}
+		},
+		func() map[string]interface{} {
+			res := unusualvalues()
+			// neither equal to themselves nor comparable by reflect.DeepEqual
+			delete(res, "nan")
+			delete(res, "chan")
+			delete(res, "func")
+			return res
+		},
+	} {
+		original := fields()
+		e := entry(slf.LevelInfo, "immutable", errors.New("err"), original)
+		handlenopanic(c.newHandler(&syncwriter{}), e)
+		if expected := fields(); !reflect.DeepEqual(original, expected) {
+			t.Errorf("expected unmodified fields %v, found %v", expected, original)
+		}
+	}
+}
+
+type panicerror struct {
+	value interface{}
+}
+
+func (p panicerror) Error() string {
+	return fmt.Sprintf("handler panicked, %v", p.value)
+}
+
+func handlenopanic(h slog.EntryHandler, e slog.Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicerror{r}
+		}
+	}()
+	return h.Handle(e)
+}
+
+func entry(level slf.Level, message string, err error, fields map[string]interface{}) slog.Entry {
+	return slog.NewEntry(time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC), level, message, err, fields)
+}
+
+// syncwriter represents a writer safe for concurrent use.
+type syncwriter struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *syncwriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *syncwriter) bytes() []byte {
+	w.Lock()
+	defer w.Unlock()
+	return w.buf.Bytes()
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slogtest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ventu-io/slog"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv defines the environment variable set to 1 to update the golden files instead of
+// comparing with them, which leaves the flags of test binaries free for their own use.
+const UpdateEnv = "SLOGTEST_UPDATE"
+
+// Golden compares the output with the content of the golden file testdata/<name>.golden of the
+// package under test, reporting the differing lines as a test error. With SLOGTEST_UPDATE=1 in
+// the environment the golden file is written instead:
+//
+//	SLOGTEST_UPDATE=1 go test ./basic -run Golden
+func Golden(tb testing.TB, name string, output []byte) {
+	tb.Helper()
+	file := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, output, 0644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(file)
+	if err != nil {
+		tb.Fatalf("%v (run with SLOGTEST_UPDATE=1 to create)", err)
+	}
+	if !bytes.Equal(expected, output) {
+		tb.Errorf("output differs from %v (run with SLOGTEST_UPDATE=1 to accept):\n%s", file, diff(string(expected), string(output)))
+	}
+}
+
+// diff lists the differing lines of the expected and the actual text.
+func diff(expected, actual string) string {
+	el, al := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	var b strings.Builder
+	for i := 0; i < len(el) || i < len(al); i++ {
+		if i < len(el) && i < len(al) && el[i] == al[i] {
+			continue
+		}
+		fmt.Fprintf(&b, "line %v:\n", i+1)
+		if i < len(el) {
+			fmt.Fprintf(&b, "\t- %q\n", el[i])
+		}
+		if i < len(al) {
+			fmt.Fprintf(&b, "\t+ %q\n", al[i])
+		}
+	}
+	return b.String()
+}
+
+// StableCallers returns a middleware rewriting the caller field of entries logged with
+// slf.CallerLong to the file path relative to the working directory, the package directory when
+// run by go test, so that the output does not depend on the location of the source tree.
+func StableCallers() slog.Middleware {
+	wd, _ := os.Getwd()
+	return func(e slog.Entry) (slog.Entry, bool) {
+		caller, ok := e.Fields()[slog.CallerField].(string)
+		if !ok || !filepath.IsAbs(caller) {
+			return e, true
+		}
+		if rel, err := filepath.Rel(wd, caller); err == nil {
+			return slog.Derive(e).SetField(slog.CallerField, filepath.ToSlash(rel)), true
+		}
+		return e, true
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slogtest_test
+
+import (
+	"flag"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/slogtest"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the test binary is free to define an update flag of its own
+var _ = flag.Bool("update", false, "unrelated update flag")
+
+func TestGolden_success(t *testing.T) {
+	slogtest.Golden(t, "sample", []byte("line 1\nline 2\n"))
+}
+
+func TestGolden_error(t *testing.T) {
+	mock := &tb{}
+	slogtest.Golden(mock, "sample", []byte("line 1\nline two\nline 3\n"))
+	if len(mock.errors) != 1 {
+		t.Fatalf("expected an error, %v", mock.errors)
+	}
+	msg := mock.errors[0]
+	for _, expected := range []string{"testdata/sample.golden", "line 2:\n\t- \"line 2\"\n\t+ \"line two\"", "line 3:\n\t- \"\"\n\t+ \"line 3\"", "line 4:\n\t+ \"\""} {
+		if !strings.Contains(msg, expected) {
+			t.Errorf("expected %q in %v", expected, msg)
+		}
+	}
+	if strings.Contains(msg, "line 1:") {
+		t.Errorf("expected only differing lines, %v", msg)
+	}
+}
+
+func TestGolden_update_success(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	t.Setenv(slogtest.UpdateEnv, "1")
+
+	slogtest.Golden(t, "updated", []byte("line 1\n"))
+	if content, err := ioutil.ReadFile("testdata/updated.golden"); err != nil || string(content) != "line 1\n" {
+		t.Errorf("expected golden file written, %q, %v", content, err)
+	}
+	t.Setenv(slogtest.UpdateEnv, "")
+	slogtest.Golden(t, "updated", []byte("line 1\n"))
+}
+
+func TestFakeClock_success(t *testing.T) {
+	start := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := slogtest.NewFakeClock(start)
+	if !clock.Now().Equal(start) || !clock.Now().Equal(start) {
+		t.Error("expected the clock to stand still")
+	}
+	clock.Add(time.Second)
+	clock.SetStep(time.Millisecond)
+	if now := clock.Now(); !now.Equal(start.Add(time.Second)) {
+		t.Errorf("unexpected time, %v", now)
+	}
+	if now := clock.Now(); !now.Equal(start.Add(time.Second + time.Millisecond)) {
+		t.Errorf("expected the clock to step, %v", now)
+	}
+	clock.Set(start)
+	if now := clock.Now(); !now.Equal(start) {
+		t.Errorf("unexpected time, %v", now)
+	}
+}
+
+func TestFakeClock_factory_success(t *testing.T) {
+	start := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := slogtest.NewFakeClock(start)
+	r := slogtest.NewRecorder()
+	lf := slog.New()
+	lf.SetClock(clock)
+	lf.AddEntryHandler(r)
+	lf.SetConcurrent(false)
+
+	logger := lf.WithContext("ctx")
+	tracer := logger.Info("started")
+	clock.Add(time.Minute)
+	tracer.Trace(nil)
+	span := logger.(slog.StructuredLogger).StartTrace("span")
+	clock.Add(time.Second)
+	span.End(nil)
+
+	entries := r.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, %v", entries)
+	}
+	if !entries[0].Time().Equal(start) || !entries[1].Time().Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected time stamps, %v %v", entries[0].Time(), entries[1].Time())
+	}
+	if d := entries[1].Fields()[slog.TraceField]; d != time.Minute {
+		t.Errorf("unexpected trace duration, %v", d)
+	}
+	if d := entries[3].Fields()[slog.TraceField]; d != time.Second {
+		t.Errorf("unexpected span duration, %v", d)
+	}
+	lf.SetClock(nil)
+	logger.Info("system")
+	if tm := r.Entries()[4].Time(); time.Since(tm) > time.Minute {
+		t.Errorf("expected the system clock, %v", tm)
+	}
+}
+
+func TestStableCallers_success(t *testing.T) {
+	r := slogtest.NewRecorder()
+	lf := slog.New()
+	lf.AddEntryHandler(r)
+	lf.SetConcurrent(false)
+	lf.Use(slogtest.StableCallers())
+	lf.SetCallerInfo(slf.CallerLong, "long")
+	lf.SetCallerInfo(slf.CallerShort, "short")
+
+	lf.WithContext("long").Info("long")
+	lf.WithContext("short").Info("short")
+	entries := r.Entries()
+	for i, e := range entries {
+		if caller, _ := e.Fields()[slog.CallerField].(string); !strings.HasPrefix(caller, "golden_test.go:") {
+			t.Errorf("%v: unexpected caller, %v", i, caller)
+		}
+	}
+}
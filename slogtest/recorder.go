@@ -0,0 +1,202 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+// Package slogtest provides helpers for testing code logging via slog: a thread-safe entry handler
+// recording entries with matchers to find them and assert on them, and an entry handler writing
+// entries to the test log, so that they are only shown for failing tests or in the verbose mode.
+package slogtest
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Matcher reports whether an entry matches a condition. Matchers passed together must all match.
+type Matcher func(e slog.Entry) bool
+
+// Level matches entries of the level.
+func Level(level slf.Level) Matcher {
+	return func(e slog.Entry) bool {
+		return e.Level() == level
+	}
+}
+
+// MinLevel matches entries at or above the level.
+func MinLevel(level slf.Level) Matcher {
+	return func(e slog.Entry) bool {
+		return e.Level() >= level
+	}
+}
+
+// Context matches entries logged in the context.
+func Context(context string) Matcher {
+	return Field(slog.ContextField, context)
+}
+
+// Message matches entries with the message matching the regular expression, it panics if the
+// expression does not compile.
+func Message(pattern string) Matcher {
+	re := regexp.MustCompile(pattern)
+	return func(e slog.Entry) bool {
+		return re.MatchString(e.Message())
+	}
+}
+
+// Field matches entries with the field of a deeply equal value. The value type must match that
+// of the field exactly, e.g. typed integer fields hold int64.
+func Field(key string, value interface{}) Matcher {
+	return func(e slog.Entry) bool {
+		v, ok := e.Fields()[key]
+		return ok && reflect.DeepEqual(v, value)
+	}
+}
+
+// HasField matches entries with the field of any value.
+func HasField(key string) Matcher {
+	return func(e slog.Entry) bool {
+		_, ok := e.Fields()[key]
+		return ok
+	}
+}
+
+// Err matches entries with an error matching the target as errors.Is does, or without an error
+// if the target is nil.
+func Err(target error) Matcher {
+	return func(e slog.Entry) bool {
+		if target == nil {
+			return e.Error() == nil
+		}
+		return errors.Is(e.Error(), target)
+	}
+}
+
+// Recorder represents an entry handler recording all entries it handles. It is safe for
+// concurrent use, entries logged concurrently can be awaited with WaitFor.
+type Recorder struct {
+	sync.Mutex
+	entries []slog.Entry
+	// closed and replaced on every recorded entry
+	changed chan struct{}
+}
+
+// NewRecorder constructs a new recording entry handler.
+func NewRecorder() *Recorder {
+	return &Recorder{changed: make(chan struct{})}
+}
+
+// NewFactory constructs a log factory logging at the debug level into a new recorder and into
+// the test log. Entries are handled concurrently, as by default, and flushed when the test ends.
+func NewFactory(tb testing.TB) (slog.LogFactory, *Recorder) {
+	res := NewRecorder()
+	lf := slog.New()
+	lf.SetLevel(slf.LevelDebug)
+	lf.SetEntryHandlers(res, NewTBHandler(tb))
+	// runs before the cleanup of the test log handler
+	tb.Cleanup(func() { lf.Flush() })
+	return lf, res
+}
+
+// Handle implements the EntryHandler interface.
+func (r *Recorder) Handle(e slog.Entry) error {
+	r.Lock()
+	r.entries = append(r.entries, e)
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.Unlock()
+	return nil
+}
+
+// Entries returns a copy of the recorded entries.
+func (r *Recorder) Entries() []slog.Entry {
+	r.Lock()
+	defer r.Unlock()
+	return append([]slog.Entry{}, r.entries...)
+}
+
+// Reset drops the recorded entries.
+func (r *Recorder) Reset() {
+	r.Lock()
+	r.entries = nil
+	r.Unlock()
+}
+
+// Find returns the recorded entries matching all matchers, all entries if none given.
+func (r *Recorder) Find(matchers ...Matcher) []slog.Entry {
+	r.Lock()
+	defer r.Unlock()
+	return find(r.entries, matchers)
+}
+
+// Count returns the number of recorded entries matching all matchers.
+func (r *Recorder) Count(matchers ...Matcher) int {
+	return len(r.Find(matchers...))
+}
+
+// AssertCount reports a test error listing the recorded entries if the number of those matching
+// all matchers differs from the expected one.
+func (r *Recorder) AssertCount(tb testing.TB, expected int, matchers ...Matcher) bool {
+	tb.Helper()
+	entries := r.Entries()
+	if found := len(find(entries, matchers)); found != expected {
+		tb.Errorf("expected %v matching entries, found %v in:%v", expected, found, describe(entries))
+		return false
+	}
+	return true
+}
+
+// WaitFor waits for an entry matching all matchers to be recorded, if not recorded already, and
+// returns the first such entry. It reports false if none was recorded within the timeout.
+func (r *Recorder) WaitFor(timeout time.Duration, matchers ...Matcher) (slog.Entry, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		r.Lock()
+		found := find(r.entries, matchers)
+		changed := r.changed
+		r.Unlock()
+		if len(found) > 0 {
+			return found[0], true
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil, false
+		}
+	}
+}
+
+func find(entries []slog.Entry, matchers []Matcher) []slog.Entry {
+	var res []slog.Entry
+	for _, e := range entries {
+		if matches(e, matchers) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func matches(e slog.Entry, matchers []Matcher) bool {
+	for _, m := range matchers {
+		if !m(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(entries []slog.Entry) string {
+	if len(entries) == 0 {
+		return " none"
+	}
+	res := ""
+	for _, e := range entries {
+		res += "\n\t" + format(e)
+	}
+	return res
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slogtest_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/slogtest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tb records test errors and log lines instead of failing the test.
+type tb struct {
+	testing.TB
+	sync.Mutex
+	errors   []string
+	lines    []string
+	cleanups []func()
+}
+
+func (t *tb) Helper() {}
+
+func (t *tb) Errorf(format string, args ...interface{}) {
+	t.Lock()
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+	t.Unlock()
+}
+
+func (t *tb) Log(args ...interface{}) {
+	t.Lock()
+	t.lines = append(t.lines, fmt.Sprint(args...))
+	t.Unlock()
+}
+
+func (t *tb) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func (t *tb) cleanup() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestRecorder_matchers_success(t *testing.T) {
+	r := slogtest.NewRecorder()
+	lf := slog.New()
+	lf.AddEntryHandler(r)
+	lf.SetConcurrent(false)
+	lf.SetLevel(slf.LevelDebug)
+	err := fmt.Errorf("wrapped: %w", errors.New("cause"))
+	cause := errors.Unwrap(err)
+
+	lf.WithContext("db").Debug("connecting")
+	lf.WithContext("db").WithField("attempt", 2).WithError(err).Warn("connection failed")
+	lf.WithContext("api").WithField("user", "joe").Info("request served")
+
+	for name, tc := range map[string]struct {
+		matchers []slogtest.Matcher
+		count    int
+	}{
+		"all":       {nil, 3},
+		"level":     {[]slogtest.Matcher{slogtest.Level(slf.LevelWarn)}, 1},
+		"min level": {[]slogtest.Matcher{slogtest.MinLevel(slf.LevelInfo)}, 2},
+		"context":   {[]slogtest.Matcher{slogtest.Context("db")}, 2},
+		"message":   {[]slogtest.Matcher{slogtest.Message("^conn")}, 2},
+		"field":     {[]slogtest.Matcher{slogtest.Field("attempt", 2)}, 1},
+		"field int": {[]slogtest.Matcher{slogtest.Field("attempt", int64(2))}, 0},
+		"has field": {[]slogtest.Matcher{slogtest.HasField("user")}, 1},
+		"err":       {[]slogtest.Matcher{slogtest.Err(cause)}, 1},
+		"no err":    {[]slogtest.Matcher{slogtest.Err(nil)}, 2},
+		"combined":  {[]slogtest.Matcher{slogtest.Context("db"), slogtest.Message("connect")}, 2},
+		"none":      {[]slogtest.Matcher{slogtest.Context("api"), slogtest.Level(slf.LevelError)}, 0},
+	} {
+		if found := r.Count(tc.matchers...); found != tc.count {
+			t.Errorf("%v: expected %v, found %v", name, tc.count, found)
+		}
+	}
+	if e := r.Find(slogtest.Context("api")); len(e) != 1 || e[0].Message() != "request served" {
+		t.Errorf("unexpected entries, %v", e)
+	}
+	r.Reset()
+	if len(r.Entries()) != 0 {
+		t.Error("expected no entries after reset")
+	}
+}
+
+func TestRecorder_assertCount_error(t *testing.T) {
+	r := slogtest.NewRecorder()
+	lf := slog.New()
+	lf.AddEntryHandler(r)
+	lf.SetConcurrent(false)
+	lf.WithContext("ctx").WithField("key", "value").Info("message")
+
+	mock := &tb{}
+	if !r.AssertCount(mock, 1, slogtest.Message("message")) || len(mock.errors) != 0 {
+		t.Errorf("expected success, %v", mock.errors)
+	}
+	if r.AssertCount(mock, 2, slogtest.Message("message")) || len(mock.errors) != 1 {
+		t.Fatalf("expected an error, %v", mock.errors)
+	}
+	if msg := mock.errors[0]; !strings.Contains(msg, "expected 2 matching entries, found 1") ||
+		!strings.Contains(msg, "INFO  [ctx] message key=value") {
+		t.Errorf("unexpected error, %v", msg)
+	}
+}
+
+func TestRecorder_waitFor_concurrent_success(t *testing.T) {
+	r := slogtest.NewRecorder()
+	lf := slog.New()
+	lf.AddEntryHandler(r)
+	logger := lf.WithContext("ctx")
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			logger.WithField("i", i).Info("tick")
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	e, ok := r.WaitFor(5*time.Second, slogtest.Field("i", 9))
+	if !ok || e.Message() != "tick" {
+		t.Errorf("expected entry, %v", e)
+	}
+	// already recorded
+	if _, ok := r.WaitFor(0, slogtest.Field("i", 9)); !ok {
+		t.Error("expected recorded entry")
+	}
+}
+
+func TestRecorder_waitFor_timeout_error(t *testing.T) {
+	r := slogtest.NewRecorder()
+	lf := slog.New()
+	lf.AddEntryHandler(r)
+	lf.WithContext("ctx").Info("other")
+
+	start := time.Now()
+	if e, ok := r.WaitFor(20*time.Millisecond, slogtest.Message("never")); ok || e != nil {
+		t.Errorf("expected timeout, %v", e)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("expected to wait for the timeout")
+	}
+}
+
+func TestNewFactory_success(t *testing.T) {
+	mock := &tb{}
+	lf, r := slogtest.NewFactory(mock)
+	lf.WithContext("ctx").WithError(errors.New("err")).Debug("debug")
+	mock.cleanup()
+	if r.AssertCount(t, 1, slogtest.Level(slf.LevelDebug)); len(mock.lines) != 1 {
+		t.Fatalf("expected 1 line, %v", mock.lines)
+	}
+	if line := mock.lines[0]; !strings.HasSuffix(line, ` DEBUG [ctx] debug error="err"`) {
+		t.Errorf("unexpected line, %v", line)
+	}
+	// dropped after the end of the test
+	lf.WithContext("ctx").Info("late")
+	lf.Flush()
+	if len(mock.lines) != 1 || r.Count() != 2 {
+		t.Errorf("expected the late entry to be recorded only, %v", mock.lines)
+	}
+}
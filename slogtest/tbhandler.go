@@ -0,0 +1,65 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slogtest
+
+import (
+	"fmt"
+	"github.com/ventu-io/slog"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TBHandler represents an entry handler writing entries to the test log via testing.TB.Log, so
+// that they are only shown for failing tests or in the verbose mode.
+type TBHandler struct {
+	sync.Mutex
+	tb   testing.TB
+	done bool
+}
+
+// NewTBHandler constructs an entry handler writing into the test log. Entries handled after the
+// test has ended, e.g. by goroutines outliving it, are dropped as the test log cannot take them.
+func NewTBHandler(tb testing.TB) *TBHandler {
+	res := &TBHandler{tb: tb}
+	tb.Cleanup(func() {
+		res.Lock()
+		res.done = true
+		res.Unlock()
+	})
+	return res
+}
+
+// Handle implements the EntryHandler interface.
+func (h *TBHandler) Handle(e slog.Entry) error {
+	h.Lock()
+	defer h.Unlock()
+	if !h.done {
+		h.tb.Log(format(e))
+	}
+	return nil
+}
+
+// format represents an entry on a single line: time, level, context, message, the remaining
+// fields sorted by key, and the error.
+func format(e slog.Entry) string {
+	fields := e.Fields()
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key != slog.ContextField {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5v [%v] %s", e.Time().Format("15:04:05.000"), e.Level(), fields[slog.ContextField], e.Message())
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, fields[key])
+	}
+	if e.Error() != nil {
+		fmt.Fprintf(&b, " error=%q", e.Error().Error())
+	}
+	return b.String()
+}
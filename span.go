@@ -0,0 +1,95 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/ventu-io/slf"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// TraceSpanField defines the key for the ID of the span started by StartTrace.
+	TraceSpanField = "trace_span"
+
+	// TraceParentField defines the key for the ID of the parent of a nested span.
+	TraceParentField = "trace_parent"
+
+	spanStarted  = " started"
+	spanFinished = " finished"
+)
+
+// Span represents a named timing started by StartTrace. Unlike logger.Trace, which times from the
+// last entry of a logger, every span keeps its own start time, so that any number of spans can be
+// outstanding and ended concurrently. A span logs an info entry when started and when ended, the
+// latter with the elapsed time under the trace key, both with the span ID and, for nested spans,
+// the ID of the parent span.
+type Span struct {
+	logger *logger
+	name   string
+	id     string
+	start  time.Time
+	ended  int32
+}
+
+func newspan(log *logger, name string, parent string) *Span {
+	var id [8]byte
+	rand.Read(id[:])
+	res := &Span{logger: log.copy(), name: name, id: hex.EncodeToString(id[:]), start: log.rootLogger.factory.now()}
+	res.logger.fields[TraceSpanField] = res.id
+	res.logger.untype(TraceSpanField)
+	delete(res.logger.fields, TraceParentField)
+	res.logger.untype(TraceParentField)
+	if parent != "" {
+		res.logger.fields[TraceParentField] = parent
+	}
+	return res
+}
+
+// StartTrace implements the StructuredLogger interface.
+func (log *logger) StartTrace(name string) *Span {
+	res := newspan(log, name, "")
+	res.logger.log(slf.LevelInfo, name+spanStarted)
+	return res
+}
+
+// StartTrace starts a span nested in this one.
+func (s *Span) StartTrace(name string) *Span {
+	res := newspan(s.logger, name, s.id)
+	res.logger.log(slf.LevelInfo, name+spanStarted)
+	return res
+}
+
+// ID returns the span ID.
+func (s *Span) ID() string {
+	return s.id
+}
+
+// Logger returns the logger of the span adding the span fields to entries logged within it.
+func (s *Span) Logger() StructuredLogger {
+	return s.logger
+}
+
+// End logs the end of the span with the elapsed time. If the error is set, it is added to the
+// entry and the level escalated to error. Only the first call has any effect, so that End can
+// be deferred while also being called explicitly:
+//
+//	span := logger.StartTrace("load")
+//	defer span.End(&err)
+func (s *Span) End(err *error) {
+	if !atomic.CompareAndSwapInt32(&s.ended, 0, 1) {
+		return
+	}
+	log := s.logger.copy()
+	log.fields[TraceField] = s.logger.rootLogger.factory.now().Sub(s.start)
+	log.untype(TraceField)
+	level := slf.LevelInfo
+	if err != nil && *err != nil {
+		log.err = *err
+		level = slf.LevelError
+	}
+	log.log(level, s.name+spanFinished)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartTrace_startAndEnd_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	logger, _ := lf.WithContext("ctx").WithCaller(slf.CallerShort).(slog.StructuredLogger)
+	span := logger.StartTrace("load")
+	span.Logger().Info("within")
+	span.End(nil)
+	span.End(nil)
+	if len(th.entries) != 3 {
+		t.Fatalf("expected 3 entries, %v", th.entries)
+	}
+	for i, msg := range []string{"load started", "within", "load finished"} {
+		e := th.entries[i]
+		fields := e.Fields()
+		if e.Message() != msg || e.Level() != slf.LevelInfo || fields[slog.TraceSpanField] != span.ID() {
+			t.Errorf("unexpected entry %v: %v %v %v", i, e.Message(), e.Level(), fields)
+		}
+		if _, ok := fields[slog.TraceParentField]; ok {
+			t.Errorf("expected no parent, %v", fields)
+		}
+		if caller, _ := fields[slog.CallerField].(string); !strings.HasPrefix(caller, "span_test.go:") {
+			t.Errorf("expected the caller of the span, %v", caller)
+		}
+	}
+	if _, ok := th.entries[2].Fields()[slog.TraceField].(time.Duration); !ok {
+		t.Errorf("expected elapsed time, %v", th.entries[2].Fields())
+	}
+}
+
+func TestStartTrace_nestedWithError_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	logger, _ := lf.WithContext("ctx").(slog.StructuredLogger)
+	outer := logger.StartTrace("outer")
+	inner := outer.StartTrace("inner")
+	err := errors.New("failed")
+	inner.End(&err)
+	outer.End(nil)
+	if len(th.entries) != 4 {
+		t.Fatalf("expected 4 entries, %v", th.entries)
+	}
+	e := th.entries[2]
+	fields := e.Fields()
+	if e.Message() != "inner finished" || e.Level() != slf.LevelError || e.Error() != err ||
+		fields[slog.TraceSpanField] != inner.ID() || fields[slog.TraceParentField] != outer.ID() {
+		t.Errorf("unexpected inner end entry, %v %v %v %v", e.Message(), e.Level(), e.Error(), fields)
+	}
+	e = th.entries[3]
+	if e.Level() != slf.LevelInfo || e.Error() != nil || e.Fields()[slog.TraceSpanField] != outer.ID() {
+		t.Errorf("unexpected outer end entry, %v %v", e.Level(), e.Fields())
+	}
+	if inner.ID() == outer.ID() {
+		t.Error("expected distinct span IDs")
+	}
+}
+
+func TestStartTrace_concurrent_success(t *testing.T) {
+	ch := &counthandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(ch)
+
+	logger, _ := lf.WithContext("ctx").(slog.StructuredLogger)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.StartTrace("work").End(nil)
+		}()
+	}
+	wg.Wait()
+	lf.Flush()
+	if ch.count != 20 {
+		t.Errorf("expected 20 entries, %v", ch.count)
+	}
+}
+
+type counthandler struct {
+	sync.Mutex
+	count int
+}
+
+func (ch *counthandler) Handle(e slog.Entry) error {
+	ch.Lock()
+	ch.count++
+	ch.Unlock()
+	return nil
+}
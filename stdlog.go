@@ -0,0 +1,156 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ventu-io/slf"
+	"io"
+	stdlog "log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// number of lines being logged by writers, atomic
+	stdwriting int32
+
+	// date, time and file prefixes of the standard logger (flags other than Lmsgprefix)
+	stdprefix = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} )?(\d{2}:\d{2}:\d{2}(\.\d{6})? )?((\S+\.go:\d+): )?`)
+
+	bracketlevel = regexp.MustCompile(`(?i)^\[(debug|info|warn|warning|error|panic|fatal)\]\s*`)
+	keyedlevel   = regexp.MustCompile(`(?i)(^|\s)level=(debug|info|warn|warning|error|panic|fatal)(\s+|$)`)
+
+	levels = map[string]slf.Level{
+		"debug":   slf.LevelDebug,
+		"info":    slf.LevelInfo,
+		"warn":    slf.LevelWarn,
+		"warning": slf.LevelWarn,
+		"error":   slf.LevelError,
+		"panic":   slf.LevelPanic,
+		"fatal":   slf.LevelFatal,
+	}
+)
+
+// StdLogWriter represents a writer turning every line written to it, e.g. by the standard library
+// logger, into a log entry of the given logger. Date and time prefixes of the standard logger are
+// stripped, a file prefix is output as the caller. Lines are never logged with the panic or fatal
+// methods, so that logging does not panic or exit.
+type StdLogWriter struct {
+	sync.Mutex
+	logger  slf.StructuredLogger
+	level   slf.Level
+	parsing bool
+	buf     []byte
+}
+
+// NewStdLogWriter constructs a writer logging every line at the given level into the logger.
+func NewStdLogWriter(logger slf.StructuredLogger, level slf.Level) *StdLogWriter {
+	return &StdLogWriter{logger: logger, level: level}
+}
+
+// SetParsingLevel toggles parsing of a level prefix such as [WARN] or a level=warn key-value
+// pair in lines, which overrides the level of the writer and is removed from the message.
+func (w *StdLogWriter) SetParsingLevel(parsing bool) {
+	w.Lock()
+	w.parsing = parsing
+	w.Unlock()
+}
+
+// Write logs every complete line, buffering an incomplete last one until it is completed.
+func (w *StdLogWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	w.buf = append(w.buf, p...)
+	lines := []string{}
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	w.buf = append([]byte{}, w.buf...)
+	parsing := w.parsing
+	w.Unlock()
+
+	for _, line := range lines {
+		w.log(line, parsing)
+	}
+	return len(p), nil
+}
+
+// Flush logs the incomplete last line, if any.
+func (w *StdLogWriter) Flush() error {
+	w.Lock()
+	line := string(w.buf)
+	w.buf = nil
+	parsing := w.parsing
+	w.Unlock()
+	w.log(line, parsing)
+	return nil
+}
+
+func (w *StdLogWriter) log(line string, parsing bool) {
+	atomic.AddInt32(&stdwriting, 1)
+	defer atomic.AddInt32(&stdwriting, -1)
+	line = strings.TrimRight(line, "\r")
+	logger := w.logger
+	if m := stdprefix.FindStringSubmatch(line); m != nil {
+		line = line[len(m[0]):]
+		if m[5] != "" {
+			// the caller of the writer is the standard logger
+			logger = logger.WithField(CallerField, m[5]).WithCaller(slf.CallerNone)
+		}
+	}
+	level := w.level
+	if parsing {
+		if m := bracketlevel.FindStringSubmatch(line); m != nil {
+			level = levels[strings.ToLower(m[1])]
+			line = line[len(m[0]):]
+		} else if m := keyedlevel.FindStringSubmatchIndex(line); m != nil {
+			level = levels[strings.ToLower(line[m[4]:m[5]])]
+			line = strings.TrimSpace(line[:m[0]] + " " + line[m[1]:])
+		}
+	}
+	if line == "" {
+		return
+	}
+	logger.Log(level, line)
+}
+
+// RedirectStdLog redirects the output of the standard library logger into the writer, setting
+// the logger flags to output the file only. The returned function restores the previous output
+// and flags. Entry handler errors, which are output via the standard logger, are written to
+// stderr while redirected to avoid an infinite loop.
+func RedirectStdLog(w *StdLogWriter) (restore func()) {
+	output, flags := stdlog.Writer(), stdlog.Flags()
+	stdlog.SetFlags(stdlog.Lshortfile)
+	stdlog.SetOutput(w)
+	return func() {
+		stdlog.SetOutput(output)
+		stdlog.SetFlags(flags)
+	}
+}
+
+// ReportHandlerError outputs an entry handler error that cannot be returned to the caller, e.g.
+// one of a wrapping handler outputting entries asynchronously, via the standard logger, or to
+// stderr while the standard logger outputs into a StdLogWriter, e.g. redirected by RedirectStdLog.
+func ReportHandlerError(err error) {
+	// the standard logger is locked while writing, so its writer cannot be checked from within
+	if atomic.LoadInt32(&stdwriting) > 0 || isStdLogWriter(stdlog.Writer()) {
+		fmt.Fprintf(os.Stderr, "log handler error: %v\n", err.Error())
+		return
+	}
+	stdlog.Printf("log handler error: %v\n", err.Error())
+}
+
+func isStdLogWriter(w io.Writer) bool {
+	_, ok := w.(*StdLogWriter)
+	return ok
+}
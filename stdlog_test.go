@@ -0,0 +1,168 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	stdlog "log"
+	"strings"
+	"testing"
+)
+
+func TestStdLogWriter_lines_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	w := slog.NewStdLogWriter(lf.WithContext("std"), slf.LevelWarn)
+	fmt.Fprint(w, "2016/01/02 15:04:05.000001 first\nsec")
+	if len(th.entries) != 1 {
+		t.Fatalf("expected only the complete line, %v", th.entries)
+	}
+	fmt.Fprint(w, "ond\n\nthird")
+	w.Flush()
+	if len(th.entries) != 3 {
+		t.Fatalf("expected 3 entries, %v", th.entries)
+	}
+	for i, msg := range []string{"first", "second", "third"} {
+		e := th.entries[i]
+		if e.Message() != msg || e.Level() != slf.LevelWarn || e.Fields()[slog.ContextField] != "std" {
+			t.Errorf("unexpected entry %v: %v %v %v", i, e.Message(), e.Level(), e.Fields())
+		}
+	}
+}
+
+func TestStdLogWriter_parsingLevel_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	lf.SetLevel(slf.LevelDebug)
+
+	w := slog.NewStdLogWriter(lf.WithContext("std"), slf.LevelInfo)
+	fmt.Fprintln(w, "[WARN] not parsed")
+	w.SetParsingLevel(true)
+	fmt.Fprintln(w, "[warn] bracketed")
+	fmt.Fprintln(w, "msg=keyed level=ERROR key=value")
+	fmt.Fprintln(w, "[FATAL] not exiting")
+	fmt.Fprintln(w, "plain")
+	expected := []struct {
+		level slf.Level
+		msg   string
+	}{
+		{slf.LevelInfo, "[WARN] not parsed"},
+		{slf.LevelWarn, "bracketed"},
+		{slf.LevelError, "msg=keyed key=value"},
+		{slf.LevelFatal, "not exiting"},
+		{slf.LevelInfo, "plain"},
+	}
+	if len(th.entries) != len(expected) {
+		t.Fatalf("expected %v entries, %v", len(expected), th.entries)
+	}
+	for i, exp := range expected {
+		if e := th.entries[i]; e.Level() != exp.level || e.Message() != exp.msg {
+			t.Errorf("expected %v %q, found %v %q", exp.level, exp.msg, e.Level(), e.Message())
+		}
+	}
+}
+
+func TestRedirectStdLog_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	output := stdlog.Writer()
+	defer stdlog.SetOutput(output)
+	restore := slog.RedirectStdLog(slog.NewStdLogWriter(lf.WithContext("std"), slf.LevelInfo))
+	stdlog.Printf("redirected %v", 25)
+	restore()
+	stdlog.SetOutput(nullwriter{})
+	stdlog.Print("not redirected")
+	if len(th.entries) != 1 {
+		t.Fatalf("expected one entry, %v", th.entries)
+	}
+	e := th.entries[0]
+	if e.Message() != "redirected 25" {
+		t.Errorf("unexpected message %q", e.Message())
+	}
+	if caller, _ := e.Fields()[slog.CallerField].(string); !strings.HasPrefix(caller, "stdlog_test.go:") {
+		t.Errorf("expected std logger caller, %v", e.Fields())
+	}
+}
+
+func TestRedirectStdLog_onHandlerError_noLoop_success(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		th := &testhandler{err: errors.New("handler error")}
+		lf := slog.New()
+		lf.AddEntryHandler(th)
+		lf.SetConcurrent(concurrent)
+
+		restore := slog.RedirectStdLog(slog.NewStdLogWriter(lf.WithContext("std"), slf.LevelInfo))
+		stdlog.Print("failing")
+		lf.Flush()
+		restore()
+		if len(th.entries) != 1 {
+			t.Errorf("expected handler error not to be logged back, %v", th.entries)
+		}
+	}
+}
+
+func TestStdLogWriter_setAsOutput_onHandlerError_noLoop_success(t *testing.T) {
+	output, flags := stdlog.Writer(), stdlog.Flags()
+	defer func() {
+		stdlog.SetOutput(output)
+		stdlog.SetFlags(flags)
+	}()
+	for _, concurrent := range []bool{false, true} {
+		th := &testhandler{err: errors.New("handler error")}
+		lf := slog.New()
+		lf.AddEntryHandler(th)
+		lf.SetConcurrent(concurrent)
+
+		stdlog.SetOutput(slog.NewStdLogWriter(lf.WithContext("std"), slf.LevelInfo))
+		stdlog.Print("failing")
+		lf.Flush()
+		if len(th.entries) != 1 {
+			t.Errorf("expected handler error not to be logged back, %v", th.entries)
+		}
+	}
+}
+
+func TestReportHandlerError_success(t *testing.T) {
+	output, flags := stdlog.Writer(), stdlog.Flags()
+	defer func() {
+		stdlog.SetOutput(output)
+		stdlog.SetFlags(flags)
+	}()
+	var buf bytes.Buffer
+	stdlog.SetOutput(&buf)
+	stdlog.SetFlags(0)
+	slog.ReportHandlerError(errors.New("boom"))
+	if buf.String() != "log handler error: boom\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	restore := slog.RedirectStdLog(slog.NewStdLogWriter(lf.WithContext("std"), slf.LevelInfo))
+	slog.ReportHandlerError(errors.New("boom"))
+	restore()
+	if len(th.entries) != 0 {
+		t.Errorf("expected error not to be logged back, %v", th.entries)
+	}
+}
+
+type nullwriter struct{}
+
+func (nullwriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
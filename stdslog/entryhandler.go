@@ -0,0 +1,236 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+//go:build go1.21
+// +build go1.21
+
+package stdslog
+
+import (
+	"context"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	goslog "log/slog"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// EntryHandler represents a slog entry handler forwarding entries as records into a log/slog
+// handler. Fields become attributes sorted by key, object marshalers become groups and the entry
+// error is added under the "error" key. The panic and fatal levels are mapped above the log/slog
+// error level.
+type EntryHandler struct {
+	handler goslog.Handler
+}
+
+// NewEntryHandler constructs an entry handler forwarding entries into the given log/slog handler.
+func NewEntryHandler(handler goslog.Handler) *EntryHandler {
+	return &EntryHandler{handler: handler}
+}
+
+// Handle forwards the log entry into the log/slog handler if it is enabled for the entry level.
+func (h *EntryHandler) Handle(e slog.Entry) error {
+	ctx := context.Background()
+	l := golevel(e.Level())
+	if !h.handler.Enabled(ctx, l) {
+		return nil
+	}
+	r := goslog.NewRecord(e.Time(), l, e.Message(), 0)
+	enc := &attrencoder{}
+	slog.VisitFields(e, enc)
+	sort.Sort(sortableattrs(enc.attrs))
+	r.AddAttrs(enc.attrs...)
+	if e.Error() != nil {
+		r.AddAttrs(goslog.Any(slog.ErrorField, e.Error()))
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+func golevel(l slf.Level) goslog.Level {
+	switch l {
+	case slf.LevelDebug:
+		return goslog.LevelDebug
+	case slf.LevelInfo:
+		return goslog.LevelInfo
+	case slf.LevelWarn:
+		return goslog.LevelWarn
+	case slf.LevelError:
+		return goslog.LevelError
+	case slf.LevelPanic:
+		return goslog.LevelError + 4
+	}
+	return goslog.LevelError + 8
+}
+
+// attrencoder converts visited fields and marshaled objects and arrays into attributes.
+type attrencoder struct {
+	attrs []goslog.Attr
+}
+
+func (enc *attrencoder) add(a goslog.Attr) {
+	enc.attrs = append(enc.attrs, a)
+}
+
+func (enc *attrencoder) VisitString(key string, value string) {
+	enc.add(goslog.String(key, value))
+}
+
+func (enc *attrencoder) VisitInt64(key string, value int64) {
+	enc.add(goslog.Int64(key, value))
+}
+
+func (enc *attrencoder) VisitFloat64(key string, value float64) {
+	enc.add(goslog.Float64(key, value))
+}
+
+func (enc *attrencoder) VisitBool(key string, value bool) {
+	enc.add(goslog.Bool(key, value))
+}
+
+func (enc *attrencoder) VisitDuration(key string, value time.Duration) {
+	enc.add(goslog.Duration(key, value))
+}
+
+func (enc *attrencoder) VisitTime(key string, value time.Time) {
+	enc.add(goslog.Time(key, value))
+}
+
+func (enc *attrencoder) VisitError(key string, value error) {
+	enc.add(goslog.Any(key, value))
+}
+
+func (enc *attrencoder) VisitObject(key string, value interface{}) {
+	enc.add(attr(key, value))
+}
+
+func (enc *attrencoder) AddString(key string, value string) {
+	enc.VisitString(key, value)
+}
+
+func (enc *attrencoder) AddInt64(key string, value int64) {
+	enc.VisitInt64(key, value)
+}
+
+func (enc *attrencoder) AddFloat64(key string, value float64) {
+	enc.VisitFloat64(key, value)
+}
+
+func (enc *attrencoder) AddBool(key string, value bool) {
+	enc.VisitBool(key, value)
+}
+
+func (enc *attrencoder) AddDuration(key string, value time.Duration) {
+	enc.VisitDuration(key, value)
+}
+
+func (enc *attrencoder) AddTime(key string, value time.Time) {
+	enc.VisitTime(key, value)
+}
+
+func (enc *attrencoder) AddObject(key string, value slog.ObjectMarshaler) error {
+	a, err := group(key, value)
+	enc.add(a)
+	return err
+}
+
+func (enc *attrencoder) AddArray(key string, value slog.ArrayMarshaler) error {
+	a, err := array(key, value)
+	enc.add(a)
+	return err
+}
+
+func (enc *attrencoder) AddReflected(key string, value interface{}) error {
+	enc.add(attr(key, value))
+	return nil
+}
+
+// attr converts object and array marshalers into groups, marshaler errors are output in place of
+// the value.
+func attr(key string, value interface{}) goslog.Attr {
+	var a goslog.Attr
+	var err error
+	switch m := value.(type) {
+	case slog.ObjectMarshaler:
+		a, err = group(key, m)
+	case slog.ArrayMarshaler:
+		a, err = array(key, m)
+	default:
+		return goslog.Any(key, value)
+	}
+	if err != nil {
+		return goslog.Any(key, err)
+	}
+	return a
+}
+
+func group(key string, value slog.ObjectMarshaler) (goslog.Attr, error) {
+	enc := &attrencoder{}
+	err := value.MarshalLogObject(enc)
+	return goslog.Attr{Key: key, Value: goslog.GroupValue(enc.attrs...)}, err
+}
+
+// array converts an array marshaler into a group keyed by element indices.
+func array(key string, value slog.ArrayMarshaler) (goslog.Attr, error) {
+	enc := &arrayencoder{}
+	err := value.MarshalLogArray(enc)
+	return goslog.Attr{Key: key, Value: goslog.GroupValue(enc.attrs...)}, err
+}
+
+type arrayencoder struct {
+	attrencoder
+}
+
+func (enc *arrayencoder) key() string {
+	return strconv.Itoa(len(enc.attrs))
+}
+
+func (enc *arrayencoder) AppendString(value string) {
+	enc.VisitString(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendInt64(value int64) {
+	enc.VisitInt64(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendFloat64(value float64) {
+	enc.VisitFloat64(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendBool(value bool) {
+	enc.VisitBool(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendDuration(value time.Duration) {
+	enc.VisitDuration(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendTime(value time.Time) {
+	enc.VisitTime(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendObject(value slog.ObjectMarshaler) error {
+	return enc.AddObject(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendArray(value slog.ArrayMarshaler) error {
+	return enc.AddArray(enc.key(), value)
+}
+
+func (enc *arrayencoder) AppendReflected(value interface{}) error {
+	return enc.AddReflected(enc.key(), value)
+}
+
+type sortableattrs []goslog.Attr
+
+func (sa sortableattrs) Len() int {
+	return len(sa)
+}
+
+func (sa sortableattrs) Swap(i, j int) {
+	sa[i], sa[j] = sa[j], sa[i]
+}
+
+func (sa sortableattrs) Less(i, j int) bool {
+	return sa[i].Key < sa[j].Key
+}
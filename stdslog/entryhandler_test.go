@@ -0,0 +1,65 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+//go:build go1.21
+// +build go1.21
+
+package stdslog_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/stdslog"
+	goslog "log/slog"
+	"strings"
+	"testing"
+)
+
+func TestEntryHandler_forwardsEntries_success(t *testing.T) {
+	var buf bytes.Buffer
+	lf := slog.New()
+	lf.AddEntryHandler(stdslog.NewEntryHandler(goslog.NewTextHandler(&buf, &goslog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a goslog.Attr) goslog.Attr {
+			if a.Key == goslog.TimeKey && len(groups) == 0 {
+				return goslog.Attr{}
+			}
+			return a
+		},
+	})))
+	lf.SetConcurrent(false)
+
+	obj := slog.ObjectMarshalerFunc(func(enc slog.ObjectEncoder) error {
+		enc.AddString("name", "jdoe")
+		return enc.AddArray("roles", slog.ArrayMarshalerFunc(func(arr slog.ArrayEncoder) error {
+			arr.AppendString("dev")
+			return nil
+		}))
+	})
+	lf.WithContext("test").WithField("user", obj).WithField("n", 5).WithError(errors.New("boom")).Warn("done")
+	expected := "level=WARN msg=done context=test n=5 user.name=jdoe user.roles.0=dev error=boom\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output, %v", buf.String())
+	}
+}
+
+func TestEntryHandler_levels_success(t *testing.T) {
+	var buf bytes.Buffer
+	lf := slog.New()
+	lf.SetLevel(slf.LevelDebug)
+	lf.AddEntryHandler(stdslog.NewEntryHandler(goslog.NewTextHandler(&buf, &goslog.HandlerOptions{Level: goslog.LevelInfo})))
+	lf.SetConcurrent(false)
+
+	func() {
+		defer func() { recover() }()
+		logger := lf.WithContext("test")
+		logger.Debug("debug")
+		logger.Error("error")
+		logger.Panic("panic")
+	}()
+	out := buf.String()
+	if strings.Contains(out, "debug") || !strings.Contains(out, "level=ERROR msg=error") || !strings.Contains(out, "level=ERROR+4 msg=panic") {
+		t.Errorf("unexpected output, %v", out)
+	}
+}
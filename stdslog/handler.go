@@ -0,0 +1,161 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+//go:build go1.21
+// +build go1.21
+
+// Package stdslog bridges slog and the standard library log/slog package in both directions:
+// Handler is a log/slog handler forwarding records into a slog log factory, while EntryHandler
+// is a slog entry handler forwarding entries into any log/slog handler. Both must not be
+// connected to each other in a cycle.
+package stdslog
+
+import (
+	"context"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	goslog "log/slog"
+	"path"
+	"runtime"
+	"strconv"
+)
+
+// Handler represents a log/slog handler forwarding records as entries into a log factory, where
+// they are filtered by level and passed to the entry handlers as any other entry. Attributes
+// become typed fields with groups mapped to dotted keys, an error attribute under the "error"
+// or "err" key becomes the entry error and a string attribute under the context key selects the
+// logging context. Fields carried by the context.Context are added as by StructuredLogger.WithCtx.
+// Entries take the time of the record, or the time by the factory clock if the record has none.
+type Handler struct {
+	factory slog.LogFactory
+	context string
+	caller  slf.CallerInfo
+	prefix  string
+	fields  []slog.Field
+	err     error
+}
+
+// NewHandler constructs a log/slog handler forwarding records into the given log factory under
+// the given logging context.
+func NewHandler(factory slog.LogFactory, context string) *Handler {
+	return &Handler{factory: factory, context: context, caller: slf.CallerShort}
+}
+
+// SetCallerInfo defines how the caller of the log/slog logger is recorded under the caller key
+// (default: slf.CallerShort).
+func (h *Handler) SetCallerInfo(caller slf.CallerInfo) {
+	h.caller = caller
+}
+
+// Enabled implements the log/slog Handler interface reporting whether the logger of the log
+// factory for the logging context is enabled for the level, without delivering the logger.
+func (h *Handler) Enabled(ctx context.Context, l goslog.Level) bool {
+	return level(l) >= h.factory.Level(h.context)
+}
+
+// Handle implements the log/slog Handler interface forwarding the record into the log factory.
+func (h *Handler) Handle(ctx context.Context, r goslog.Record) error {
+	res := h.clone()
+	r.Attrs(func(a goslog.Attr) bool {
+		res.add(h.prefix, a)
+		return true
+	})
+	if h.caller != slf.CallerNone && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file := frame.File
+		if h.caller == slf.CallerShort {
+			file = path.Base(file)
+		}
+		res.fields = append(res.fields, slog.String(slog.CallerField, file+":"+strconv.Itoa(frame.Line)))
+	}
+	logger, _ := h.factory.WithContext(res.context).(slog.StructuredLogger)
+	if ctx != nil {
+		logger = logger.WithCtx(ctx)
+	}
+	// caller already recorded: the caller of the factory logger would be this handler
+	l := logger.WithTypedFields(res.fields...).WithCaller(slf.CallerNone)
+	slog.Events(l).Level(level(r.Level)).At(r.Time).Err(res.err).Msg(r.Message)
+	return nil
+}
+
+// WithAttrs implements the log/slog Handler interface.
+func (h *Handler) WithAttrs(attrs []goslog.Attr) goslog.Handler {
+	res := h.clone()
+	for _, a := range attrs {
+		res.add(h.prefix, a)
+	}
+	return res
+}
+
+// WithGroup implements the log/slog Handler interface.
+func (h *Handler) WithGroup(name string) goslog.Handler {
+	if name == "" {
+		return h
+	}
+	res := h.clone()
+	res.prefix = h.prefix + name + "."
+	return res
+}
+
+func (h *Handler) clone() *Handler {
+	res := *h
+	res.fields = append([]slog.Field{}, h.fields...)
+	return &res
+}
+
+func (h *Handler) add(prefix string, a goslog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(goslog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == goslog.KindGroup {
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			h.add(prefix, ga)
+		}
+		return
+	}
+	key := prefix + a.Key
+	switch a.Value.Kind() {
+	case goslog.KindString:
+		if key == slog.ContextField {
+			h.context = a.Value.String()
+			return
+		}
+		h.fields = append(h.fields, slog.String(key, a.Value.String()))
+	case goslog.KindInt64:
+		h.fields = append(h.fields, slog.Int64(key, a.Value.Int64()))
+	case goslog.KindFloat64:
+		h.fields = append(h.fields, slog.Float64(key, a.Value.Float64()))
+	case goslog.KindBool:
+		h.fields = append(h.fields, slog.Bool(key, a.Value.Bool()))
+	case goslog.KindDuration:
+		h.fields = append(h.fields, slog.Duration(key, a.Value.Duration()))
+	case goslog.KindTime:
+		h.fields = append(h.fields, slog.Time(key, a.Value.Time()))
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			if key == slog.ErrorField || key == "err" {
+				h.err = err
+				return
+			}
+			h.fields = append(h.fields, slog.NamedErr(key, err))
+			return
+		}
+		h.fields = append(h.fields, slog.Object(key, a.Value.Any()))
+	}
+}
+
+func level(l goslog.Level) slf.Level {
+	switch {
+	case l < goslog.LevelInfo:
+		return slf.LevelDebug
+	case l < goslog.LevelWarn:
+		return slf.LevelInfo
+	case l < goslog.LevelError:
+		return slf.LevelWarn
+	}
+	return slf.LevelError
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+//go:build go1.21
+// +build go1.21
+
+package stdslog_test
+
+import (
+	"context"
+	"errors"
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"github.com/ventu-io/slog/stdslog"
+	goslog "log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testhandler struct {
+	entries []slog.Entry
+}
+
+func (th *testhandler) Handle(entry slog.Entry) error {
+	th.entries = append(th.entries, entry)
+	return nil
+}
+
+func TestHandler_forwardsRecords_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	logger := goslog.New(stdslog.NewHandler(lf, "std"))
+	logger.With("service", "app").WithGroup("req").Info("handled", "status", 200,
+		goslog.Group("timing", "total", time.Second), "ok", true, "ratio", 0.5)
+	if len(th.entries) != 1 {
+		t.Fatalf("expected 1 entry, %v", th.entries)
+	}
+	e := th.entries[0]
+	if e.Message() != "handled" || e.Level() != slf.LevelInfo || e.Error() != nil {
+		t.Errorf("unexpected entry, %v", e)
+	}
+	fields := e.Fields()
+	if fields[slog.ContextField] != "std" || fields["service"] != "app" || fields["req.status"] != int64(200) ||
+		fields["req.timing.total"] != time.Second || fields["req.ok"] != true || fields["req.ratio"] != 0.5 {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+	if caller, _ := fields[slog.CallerField].(string); !strings.HasPrefix(caller, "handler_test.go:") {
+		t.Errorf("unexpected caller, %v", caller)
+	}
+}
+
+func TestHandler_levelsContextAndError_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	lf.SetLevel(slf.LevelWarn, "filtered")
+
+	h := stdslog.NewHandler(lf, "std")
+	h.SetCallerInfo(slf.CallerNone)
+	logger := goslog.New(h)
+	logger.Debug("debug")
+	logger.Warn("warn", "context", "other", "err", errors.New("boom"))
+	logger.Error("error", "cause", errors.New("cause"))
+	logger.With("context", "filtered").Info("filtered")
+	logger.Log(context.Background(), goslog.LevelError+4, "above error")
+
+	if len(th.entries) != 3 {
+		t.Fatalf("expected 3 entries, %v", th.entries)
+	}
+	warn := th.entries[0]
+	if warn.Level() != slf.LevelWarn || warn.Fields()[slog.ContextField] != "other" || warn.Error().Error() != "boom" {
+		t.Errorf("unexpected entry, %v", warn)
+	}
+	if _, ok := warn.Fields()[slog.CallerField]; ok {
+		t.Error("unexpected caller")
+	}
+	e := th.entries[1]
+	if e.Level() != slf.LevelError || e.Error() != nil || e.Fields()["cause"].(error).Error() != "cause" {
+		t.Errorf("unexpected entry, %v", e.Fields())
+	}
+	if th.entries[2].Level() != slf.LevelError {
+		t.Errorf("unexpected level, %v", th.entries[2].Level())
+	}
+}
+
+func TestHandler_contextFields_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	ctx := slog.NewFieldsContext(context.Background(), slf.Fields{"request_id": "r-25"})
+	goslog.New(stdslog.NewHandler(lf, "std")).InfoContext(ctx, "handled")
+	if len(th.entries) != 1 {
+		t.Fatalf("expected 1 entry, %v", th.entries)
+	}
+	if fields := th.entries[0].Fields(); fields["request_id"] != "r-25" {
+		t.Errorf("expected fields from context, %v", fields)
+	}
+}
+
+func TestHandler_enabled_delegatesToLogger_success(t *testing.T) {
+	lf := slog.New()
+	lf.SetLevel(slf.LevelWarn, "std")
+
+	h := stdslog.NewHandler(lf, "std")
+	if h.Enabled(context.Background(), goslog.LevelInfo) || !h.Enabled(context.Background(), goslog.LevelWarn) {
+		t.Error("expected level of the logging context")
+	}
+	if h := stdslog.NewHandler(lf, "other"); !h.Enabled(context.Background(), goslog.LevelInfo) {
+		t.Error("expected default level")
+	}
+	if _, ok := lf.Contexts()["other"]; ok {
+		t.Error("expected no logger delivered for the context")
+	}
+}
+
+func TestHandler_recordTime_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	tm := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := stdslog.NewHandler(lf, "std")
+	r := goslog.NewRecord(tm, goslog.LevelInfo, "recorded", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	if err := h.Handle(context.Background(), goslog.NewRecord(time.Time{}, goslog.LevelInfo, "untimed", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(th.entries) != 2 || !th.entries[0].Time().Equal(tm) || th.entries[1].Time().Before(before) {
+		t.Errorf("unexpected time stamps, %v", th.entries)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/ventu-io/slf"
+)
+
+const (
+	// TraceIDField defines the key for the W3C trace context trace identifier.
+	TraceIDField = "trace_id"
+
+	// SpanIDField defines the key for the W3C trace context span identifier.
+	SpanIDField = "span_id"
+
+	// TraceFlagsField defines the key for the W3C trace context trace flags.
+	TraceFlagsField = "trace_flags"
+)
+
+// SpanContext represents the W3C trace context identifiers of a span used to correlate log
+// entries with traces.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether both the trace and span identifiers are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Fields returns the identifiers as lowercase hex strings under the trace_id, span_id and
+// trace_flags keys.
+func (sc SpanContext) Fields() slf.Fields {
+	return slf.Fields{
+		TraceIDField:    hex.EncodeToString(sc.TraceID[:]),
+		SpanIDField:     hex.EncodeToString(sc.SpanID[:]),
+		TraceFlagsField: hex.EncodeToString([]byte{sc.Flags}),
+	}
+}
+
+// Traceparent formats the span context as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses the value of a W3C traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var res SpanContext
+	// version 00 has exactly 4 parts, future versions may append further ones
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' ||
+		(len(value) > 55 && (value[:2] == "00" || value[55] != '-')) {
+		return res, fmt.Errorf("slog: malformed traceparent %q", value)
+	}
+	var version, flags [1]byte
+	if !decodehex(version[:], value[:2]) || version[0] == 0xff ||
+		!decodehex(res.TraceID[:], value[3:35]) || !decodehex(res.SpanID[:], value[36:52]) ||
+		!decodehex(flags[:], value[53:55]) {
+		return SpanContext{}, fmt.Errorf("slog: malformed traceparent %q", value)
+	}
+	res.Flags = flags[0]
+	if !res.IsValid() {
+		return SpanContext{}, fmt.Errorf("slog: invalid traceparent %q", value)
+	}
+	return res, nil
+}
+
+// decodehex decodes lowercase hex only as required by the W3C trace context.
+func decodehex(dst []byte, src string) bool {
+	for i := 0; i < len(src); i++ {
+		if c := src[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	_, err := hex.Decode(dst, []byte(src))
+	return err == nil
+}
+
+type spankey struct{}
+
+// NewSpanContext returns a copy of the context carrying the span context.
+func NewSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spankey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by the context, if any.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spankey{}).(SpanContext)
+	return sc, ok
+}
+
+// SpanContextProvider delivers the span context of the span active in a context.Context, it
+// plugs in a tracing SDK without slog depending on it.
+type SpanContextProvider interface {
+	SpanContext(ctx context.Context) (SpanContext, bool)
+}
+
+// SpanContextProviderFunc is a function implementing SpanContextProvider.
+type SpanContextProviderFunc func(ctx context.Context) (SpanContext, bool)
+
+// SpanContext implements the SpanContextProvider interface.
+func (f SpanContextProviderFunc) SpanContext(ctx context.Context) (SpanContext, bool) {
+	return f(ctx)
+}
+
+// TraceExtractor returns a context extractor adding the trace_id, span_id and trace_flags fields
+// of a valid span context delivered by the provider, or carried by the context if the provider is
+// nil, for use with LogFactory.AddContextExtractor.
+func TraceExtractor(provider SpanContextProvider) ContextExtractor {
+	if provider == nil {
+		provider = SpanContextProviderFunc(SpanContextFromContext)
+	}
+	return func(ctx context.Context) slf.Fields {
+		if sc, ok := provider.SpanContext(ctx); ok && sc.IsValid() {
+			return sc.Fields()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"context"
+	"github.com/ventu-io/slog"
+	"testing"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent_success(t *testing.T) {
+	sc, err := slog.ParseTraceparent(traceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.IsValid() || sc.Flags != 1 || sc.TraceID[0] != 0x4b || sc.SpanID[7] != 0xb7 {
+		t.Errorf("unexpected span context, %v", sc)
+	}
+	if sc.Traceparent() != traceparent {
+		t.Errorf("unexpected traceparent, %v", sc.Traceparent())
+	}
+	fields := sc.Fields()
+	if fields[slog.TraceIDField] != "4bf92f3577b34da6a3ce929d0e0e4736" || fields[slog.SpanIDField] != "00f067aa0ba902b7" ||
+		fields[slog.TraceFlagsField] != "01" {
+		t.Errorf("unexpected fields, %v", fields)
+	}
+	// future versions may carry further parts
+	if _, err := slog.ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseTraceparent_error(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+	} {
+		if _, err := slog.ParseTraceparent(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestTraceExtractor_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	lf.AddContextExtractor(slog.TraceExtractor(nil))
+
+	sc, _ := slog.ParseTraceparent(traceparent)
+	logger, _ := lf.WithContext("ctx").(slog.StructuredLogger)
+	logger.WithCtx(slog.NewSpanContext(context.Background(), sc)).Info("traced")
+	logger.WithCtx(context.Background()).Info("untraced")
+	if len(th.entries) != 2 {
+		t.Fatalf("expected 2 entries, %v", th.entries)
+	}
+	if fields := th.entries[0].Fields(); fields[slog.TraceIDField] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected trace fields, %v", fields)
+	}
+	if fields := th.entries[1].Fields(); len(fields) != 1 {
+		t.Errorf("expected no trace fields, %v", fields)
+	}
+}
+
+func TestTraceExtractor_provider_success(t *testing.T) {
+	sc, _ := slog.ParseTraceparent(traceparent)
+	extract := slog.TraceExtractor(slog.SpanContextProviderFunc(func(ctx context.Context) (slog.SpanContext, bool) {
+		return sc, true
+	}))
+	if fields := extract(context.Background()); fields[slog.SpanIDField] != "00f067aa0ba902b7" {
+		t.Errorf("expected fields of provided span context, %v", fields)
+	}
+	extract = slog.TraceExtractor(slog.SpanContextProviderFunc(func(ctx context.Context) (slog.SpanContext, bool) {
+		return slog.SpanContext{}, true
+	}))
+	if fields := extract(context.Background()); fields != nil {
+		t.Errorf("expected no fields for invalid span context, %v", fields)
+	}
+}
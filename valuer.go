@@ -0,0 +1,34 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog
+
+import (
+	"fmt"
+)
+
+// Valuer represents a lazily evaluated field value. Field values of this type, or of the plain
+// func() interface{} type, are evaluated when a log entry is created, that is only for entries
+// passing the minimum log level, and once per entry. A panic in the valuer does not reach the
+// caller, but is output as the field value instead.
+type Valuer func() interface{}
+
+// resolve evaluates the value if it is a valuer.
+func resolve(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Valuer:
+		return evaluate(v)
+	case func() interface{}:
+		return evaluate(v)
+	}
+	return value
+}
+
+func evaluate(valuer func() interface{}) (res interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = fmt.Sprintf("!(PANIC=%v)", r)
+		}
+	}()
+	return valuer()
+}
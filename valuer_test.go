@@ -0,0 +1,63 @@
+// Copyright (c) 2016 Ventu.io, Oleg Sklyar, contributors
+// The use of this source code is governed by a MIT style license found in the LICENSE file
+
+package slog_test
+
+import (
+	"github.com/ventu-io/slf"
+	"github.com/ventu-io/slog"
+	"testing"
+)
+
+func TestValuer_evaluatedOncePerEmittedEntry_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	calls := 0
+	logger := lf.WithContext("test").WithField("lazy", slog.Valuer(func() interface{} {
+		calls++
+		return calls
+	}))
+	logger.Debug("filtered")
+	if calls != 0 {
+		t.Error("expected no evaluation for filtered entry")
+	}
+	logger.Info("info0")
+	logger.Info("info1")
+	if calls != 2 {
+		t.Errorf("expected one evaluation per entry, %v", calls)
+	}
+	if th.entries[0].Fields()["lazy"] != 1 || th.entries[1].Fields()["lazy"] != 2 {
+		t.Errorf("unexpected values, %v, %v", th.entries[0].Fields(), th.entries[1].Fields())
+	}
+}
+
+func TestValuer_plainFuncAndDefaultFields_success(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+	lf.SetFields(slf.Fields{"default": func() interface{} { return "evaluated" }})
+
+	lf.WithContext("test").WithFields(slf.Fields{"plain": func() interface{} { return 25 }}).Info("info")
+	fields := th.entries[0].Fields()
+	if fields["default"] != "evaluated" || fields["plain"] != 25 {
+		t.Errorf("unexpected values, %v", fields)
+	}
+}
+
+func TestValuer_onPanic_capturedInField(t *testing.T) {
+	th := &testhandler{}
+	lf := slog.New()
+	lf.AddEntryHandler(th)
+	lf.SetConcurrent(false)
+
+	lf.WithContext("test").WithField("lazy", slog.Valuer(func() interface{} {
+		panic("boom")
+	})).Info("info")
+	if val := th.entries[0].Fields()["lazy"]; val != "!(PANIC=boom)" {
+		t.Errorf("unexpected value, %v", val)
+	}
+}